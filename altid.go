@@ -0,0 +1,169 @@
+/**
+ * alternative representations of a property (RFC 6350 5.4): the properties with the same name and the same ALTID
+ * are one logical property (ex: a name in Latin and in native script), usually told apart by their LANGUAGE
+ *
+ *   FN;ALTID=1;LANGUAGE=ja:山田太郎
+ *   FN;ALTID=1;LANGUAGE=en:Taro Yamada
+ */
+package vcard
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * ALTID of a property; empty if not set
+ */
+func altIdOf(p IProperty) string {
+	if param := p.GetParameter("ALTID"); param != nil && len(param.GetValue()) > 0 {
+		return param.GetValue()[0]
+	}
+	return ""
+}
+
+/**
+ * LANGUAGE of a property; empty if not set
+ */
+func languageOf(p IProperty) string {
+	if param := p.GetParameter("LANGUAGE"); param != nil && len(param.GetValue()) > 0 {
+		return param.GetValue()[0]
+	}
+	return ""
+}
+
+/**
+ * a and b are representations of the same logical property
+ */
+func isAlternative(a IProperty, b IProperty) bool {
+	altId := altIdOf(a)
+	return altId != "" && a.GetName() == b.GetName() && altIdOf(b) == altId
+}
+
+/**
+ * an ALTID not used by the properties of the card (1 + the greatest numeric ALTID)
+ */
+func NextAltId(card IVCard) string {
+	next := 1
+	for _, p := range card.GetProperties() {
+		if n, err := strconv.Atoi(altIdOf(p)); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return strconv.Itoa(next)
+}
+
+/**
+ * add the properties as representations of the same logical property: they get a new ALTID and the LANGUAGE
+ * of the same index (an empty language is not set); return the ALTID
+ */
+func AddAlternatives(card IVCard, props []IProperty, languages []string) string {
+	altId := NextAltId(card)
+	for i, p := range props {
+		language := ""
+		if i < len(languages) {
+			language = languages[i]
+		}
+		AddAlternative(card, altId, p, language)
+	}
+	return altId
+}
+
+/**
+ * add a property to the representations with the given ALTID
+ */
+func AddAlternative(card IVCard, altId string, p IProperty, language string) {
+	p.DeleteParameter("ALTID")
+	card.AddPropertyParameter(p, "ALTID", []string{altId})
+	if language != "" {
+		p.DeleteParameter("LANGUAGE")
+		card.AddPropertyParameter(p, "LANGUAGE", []string{language})
+	}
+	card.AddProperty(p)
+}
+
+/**
+ * properties of a name grouped by ALTID, in the order of the card; a property without ALTID is alone in its group
+ */
+func GetAlternatives(card IVCard, name string) [][]IProperty {
+	var result [][]IProperty
+	index := map[string]int{}
+	for _, p := range card.GetProperty(name) {
+		altId := altIdOf(p)
+		if altId == "" {
+			result = append(result, []IProperty{p})
+			continue
+		}
+		if i, ok := index[altId]; ok {
+			result[i] = append(result[i], p)
+			continue
+		}
+		index[altId] = len(result)
+		result = append(result, []IProperty{p})
+	}
+	return result
+}
+
+/**
+ * the representation that best matches the preferred languages (language tags, most preferred first), with the
+ * lookup scheme of RFC 4647 3.4: "de-CH-1996" matches de-CH-1996, then de-CH, then de; "*" matches any language
+ * without match, the representation without LANGUAGE or else the first one; nil if alternatives is empty
+ */
+func BestAlternative(alternatives []IProperty, preferred []string) IProperty {
+	for _, languageRange := range preferred {
+		languageRange = strings.ToLower(strings.TrimSpace(languageRange))
+		if languageRange == "*" {
+			for _, p := range alternatives {
+				if languageOf(p) != "" {
+					return p
+				}
+			}
+			continue
+		}
+		for languageRange != "" {
+			for _, p := range alternatives {
+				if strings.EqualFold(languageOf(p), languageRange) {
+					return p
+				}
+			}
+			languageRange = truncateLanguageRange(languageRange)
+		}
+	}
+
+	for _, p := range alternatives {
+		if languageOf(p) == "" {
+			return p
+		}
+	}
+	if len(alternatives) > 0 {
+		return alternatives[0]
+	}
+	return nil
+}
+
+/**
+ * remove the last subtag of a language range, and the single letter subtag (extension, private use) before it
+ *   zh-hant-cn-x-private1 -> zh-hant-cn -> zh-hant -> zh
+ */
+func truncateLanguageRange(languageRange string) string {
+	i := strings.LastIndexByte(languageRange, '-')
+	if i < 0 {
+		return ""
+	}
+	languageRange = languageRange[:i]
+	if j := strings.LastIndexByte(languageRange, '-'); j >= 0 && len(languageRange)-j == 2 {
+		languageRange = languageRange[:j]
+	}
+	return languageRange
+}
+
+/**
+ * the best representation of each logical property of a name (see BestAlternative), in the order of the card
+ */
+func GetPropertiesForLanguages(card IVCard, name string, preferred []string) []IProperty {
+	var result []IProperty
+	for _, alternatives := range GetAlternatives(card, name) {
+		result = append(result, BestAlternative(alternatives, preferred))
+	}
+	return result
+}
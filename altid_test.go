@@ -0,0 +1,94 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func newTextProperty(card IVCard, name string, value string) IProperty {
+	p := card.CreateProperty(name)
+	p.AddValue(NewText(value))
+	return p
+}
+
+func TestAddAlternatives(t *testing.T) {
+	card := NewVCardV4()
+	card.AddProperty(newTextProperty(card, "FN", "Taro"))
+	altId := AddAlternatives(card, []IProperty{
+		newTextProperty(card, "FN", "山田太郎"),
+		newTextProperty(card, "FN", "Taro Yamada"),
+	}, []string{"ja", "en"})
+	if altId != "1" || NextAltId(card) != "2" {
+		t.Errorf("ALTID %q, next %q", altId, NextAltId(card))
+	}
+
+	groups := GetAlternatives(card, "FN")
+	if len(groups) != 2 || len(groups[0]) != 1 || len(groups[1]) != 2 {
+		t.Fatalf("alternatives: %v", groups)
+	}
+	if languageOf(groups[1][0]) != "ja" || altIdOf(groups[1][1]) != "1" {
+		t.Errorf("parameters of the alternatives: %v", groups[1][0].GetParameters())
+	}
+
+	// N may appear once: its representations are one property for the cardinality
+	n := []IProperty{newTextProperty(card, "N", "Yamada"), newTextProperty(card, "N", "山田")}
+	AddAlternatives(card, n, []string{"en", "ja"})
+	if len(card.GetProperty("N")) != 2 {
+		t.Errorf("the representations of N overwrite each other: %d", len(card.GetProperty("N")))
+	}
+	card.AddProperty(newTextProperty(card, "N", "Doe"))
+	if len(card.GetProperty("N")) != 1 {
+		t.Errorf("a new N does not overwrite the representations: %d", len(card.GetProperty("N")))
+	}
+}
+
+func TestBestAlternative(t *testing.T) {
+	card := NewVCardV4()
+	props := []IProperty{
+		newTextProperty(card, "FN", "neutral"),
+		newTextProperty(card, "FN", "German"),
+		newTextProperty(card, "FN", "Swiss German"),
+		newTextProperty(card, "FN", "Chinese"),
+	}
+	AddAlternatives(card, props, []string{"", "de", "de-CH", "zh-Hant"})
+
+	tests := []struct {
+		preferred []string
+		want string
+	}{
+		{[]string{"de-CH-1996"}, "Swiss German"},
+		{[]string{"de-AT"}, "German"},
+		{[]string{"zh-Hant-CN-x-private1"}, "Chinese"},
+		{[]string{"fr", "DE"}, "German"},
+		{[]string{"fr"}, "neutral"},
+		{[]string{"*"}, "German"},
+		{nil, "neutral"},
+	}
+	for _, tt := range tests {
+		if got := BestAlternative(props, tt.preferred); got.GetFirstValue().GetString() != tt.want {
+			t.Errorf("%v: %q, want %q", tt.preferred, got.GetFirstValue().GetString(), tt.want)
+		}
+	}
+	if BestAlternative(props[1:2], []string{"fr"}) != props[1] || BestAlternative(nil, []string{"fr"}) != nil {
+		t.Errorf("fallback to the first representation")
+	}
+
+	card.AddProperty(newTextProperty(card, "FN", "other"))
+	best := GetPropertiesForLanguages(card, "FN", []string{"de"})
+	if len(best) != 2 || best[0].GetFirstValue().GetString() != "German" || best[1].GetFirstValue().GetString() != "other" {
+		t.Errorf("GetPropertiesForLanguages: %v", best)
+	}
+}
+
+func TestParseAlternatives(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:4.0\r\nFN;ALTID=1;LANGUAGE=ja:山田太郎\r\nFN;ALTID=1;LANGUAGE=en:Taro Yamada\r\nN;ALTID=2;LANGUAGE=ja:山田;太郎;;;\r\nN;ALTID=2;LANGUAGE=en:Yamada;Taro;;;\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(card.GetProperty("N")) != 2 {
+		t.Errorf("the representations of N are dropped: %d", len(card.GetProperty("N")))
+	}
+	fn := GetPropertiesForLanguages(card, "FN", []string{"en-US"})
+	if len(fn) != 1 || fn[0].GetFirstValue().GetString() != "Taro Yamada" {
+		t.Errorf("FN for en-US: %v", fn)
+	}
+}
@@ -0,0 +1,321 @@
+/**
+ * IBuilder implementation: the card management methods and the property creators
+ * the creators return properties configured by the card (cardinality, value types, multiple values and accepted
+ * parameters - see schema.go); the creators of the properties not defined in the version of the card
+ * (NewAgentProperty for vcard 4.0, NewKindProperty for vcard 3.0...) return nil
+ */
+package vcard
+
+import (
+	"strings"
+)
+
+/**
+ * card with a property scenario (see baseVCard)
+ */
+type addPropertyScenarioSetter interface {
+	SetAddPropertyScenario(v string)
+	GetAddPropertyScenario() string
+}
+
+func (b *Builder) GetVersion() string {
+	return b.vcard.GetVersion()
+}
+
+/**
+ * the card rendered by the builder
+ */
+func (b *Builder) GetVCard() IVCard {
+	return b.vcard
+}
+
+func (b *Builder) AddProperty(p IProperty) {
+	b.vcard.AddProperty(p)
+}
+
+/**
+ * attach a parameter to a property with the parameter rules of the card version
+ */
+func (b *Builder) AddPropertyParameter(p IProperty, name string, values []string) {
+	b.vcard.AddPropertyParameter(p, name, values)
+}
+
+func (b *Builder) GetProperty(name string) []IProperty {
+	return b.vcard.GetProperty(name)
+}
+
+func (b *Builder) DeleteProperty(name string) {
+	b.vcard.DeleteProperty(name)
+}
+
+/**
+ * ignore / overwrite, applied to the card (see IBuilder)
+ */
+func (b *Builder) SetAddPropertyScenario(v string) {
+	if v != "ignore" {
+		v = "overwrite"
+	}
+	b.addPropertyScenario = v
+	if vc, ok := b.vcard.(addPropertyScenarioSetter); ok {
+		vc.SetAddPropertyScenario(v)
+	}
+}
+
+func (b *Builder) GetAddPropertyScenario() string {
+	if vc, ok := b.vcard.(addPropertyScenarioSetter); ok {
+		return vc.GetAddPropertyScenario()
+	}
+	if b.addPropertyScenario != "ignore" {
+		return "overwrite"
+	}
+	return b.addPropertyScenario
+}
+
+/**
+ * create a property of the card; nil if the version of the card does not define it
+ */
+func (b *Builder) newProperty(name string) *VCardProperty {
+	if !isPropertyDefined(b.vcard.GetVersion(), name) {
+		return nil
+	}
+	cp := b.vcard.CreateProperty(name)
+	if cp == nil {
+		return nil
+	}
+	if p, ok := cp.(*VCardProperty); ok {
+		return p
+	}
+	// cards that create their own property types: a generic property with the same rules
+	p := NewProperty(name)
+	p.SetCardinality(cp.GetCardinality())
+	p.SetAllowMultipleValues(cp.GetAllowMultipleValues())
+	p.SetAcceptedValueTypes(cp.GetAcceptedValueTypes())
+	if schema, ok := lookupPropertySchema(b.vcard.GetVersion(), p.GetName()); ok {
+		p.SetAcceptedParameters(schema.Parameters)
+	}
+	p.SetValue(cp.GetValue())
+	return p
+}
+
+func (b *Builder) NewBeginProperty() *VCardProperty {
+	return b.newProperty("BEGIN")
+}
+
+func (b *Builder) NewEndProperty() *VCardProperty {
+	return b.newProperty("END")
+}
+
+func (b *Builder) NewVersionProperty() *VCardProperty {
+	return b.newProperty("VERSION")
+}
+
+/**
+ * extension property; the X- prefix is added if missing
+ */
+func (b *Builder) NewCustomProperty(name string) *VCardProperty {
+	if !strings.HasPrefix(strings.ToUpper(name), "X-") {
+		name = "X-" + name
+	}
+	return b.newProperty(name)
+}
+
+func (b *Builder) NewFBUrlProperty() *VCardProperty {
+	return b.newProperty("FBURL")
+}
+
+func (b *Builder) NewClassProperty() *VCardProperty {
+	return b.newProperty("CLASS")
+}
+
+func (b *Builder) NewNProperty() *VCardProperty {
+	return b.newProperty("N")
+}
+
+func (b *Builder) NewFnProperty() *VCardProperty {
+	return b.newProperty("FN")
+}
+
+func (b *Builder) NewBDayProperty() *VCardProperty {
+	return b.newProperty("BDAY")
+}
+
+func (b *Builder) NewAnniversaryProperty() *VCardProperty {
+	return b.newProperty("ANNIVERSARY")
+}
+
+func (b *Builder) NewAdrProperty() *VCardProperty {
+	return b.newProperty("ADR")
+}
+
+func (b *Builder) NewTelProperty() *VCardProperty {
+	return b.newProperty("TEL")
+}
+
+func (b *Builder) NewEmailProperty() *VCardProperty {
+	return b.newProperty("EMAIL")
+}
+
+func (b *Builder) NewNicknameProperty() *VCardProperty {
+	return b.newProperty("NICKNAME")
+}
+
+func (b *Builder) NewPhotoProperty() *VCardProperty {
+	return b.newProperty("PHOTO")
+}
+
+func (b *Builder) NewUrlProperty() *VCardProperty {
+	return b.newProperty("URL")
+}
+
+func (b *Builder) NewKeyProperty() *VCardProperty {
+	return b.newProperty("KEY")
+}
+
+func (b *Builder) NewSoundProperty() *VCardProperty {
+	return b.newProperty("SOUND")
+}
+
+func (b *Builder) NewUidProperty() *VCardProperty {
+	return b.newProperty("UID")
+}
+
+func (b *Builder) NewTzProperty() *VCardProperty {
+	return b.newProperty("TZ")
+}
+
+func (b *Builder) NewTitleProperty() *VCardProperty {
+	return b.newProperty("TITLE")
+}
+
+func (b *Builder) NewRoleProperty() *VCardProperty {
+	return b.newProperty("ROLE")
+}
+
+func (b *Builder) NewLogoProperty() *VCardProperty {
+	return b.newProperty("LOGO")
+}
+
+func (b *Builder) NewOrgProperty() *VCardProperty {
+	return b.newProperty("ORG")
+}
+
+func (b *Builder) NewCategoriesProperty() *VCardProperty {
+	return b.newProperty("CATEGORIES")
+}
+
+func (b *Builder) NewNoteProperty() *VCardProperty {
+	return b.newProperty("NOTE")
+}
+
+func (b *Builder) NewProdIdProperty() *VCardProperty {
+	return b.newProperty("PRODID")
+}
+
+func (b *Builder) NewRevProperty() *VCardProperty {
+	return b.newProperty("REV")
+}
+
+func (b *Builder) NewGeoProperty() *VCardProperty {
+	return b.newProperty("GEO")
+}
+
+func (b *Builder) NewSourceProperty() *VCardProperty {
+	return b.newProperty("SOURCE")
+}
+
+func (b *Builder) NewLabelProperty() *VCardProperty {
+	return b.newProperty("LABEL")
+}
+
+func (b *Builder) NewCalAdrUriProperty() *VCardProperty {
+	return b.newProperty("CALADRURI")
+}
+
+func (b *Builder) NewCalUriProperty() *VCardProperty {
+	return b.newProperty("CALURI")
+}
+
+func (b *Builder) NewRelatedProperty() *VCardProperty {
+	return b.newProperty("RELATED")
+}
+
+func (b *Builder) NewAgentProperty() *VCardProperty {
+	return b.newProperty("AGENT")
+}
+
+func (b *Builder) NewSortStringProperty() *VCardProperty {
+	return b.newProperty("SORT-STRING")
+}
+
+func (b *Builder) NewMailerProperty() *VCardProperty {
+	return b.newProperty("MAILER")
+}
+
+func (b *Builder) NewNameProperty() *VCardProperty {
+	return b.newProperty("NAME")
+}
+
+func (b *Builder) NewProfileProperty() *VCardProperty {
+	return b.newProperty("PROFILE")
+}
+
+func (b *Builder) NewXmlProperty() *VCardProperty {
+	return b.newProperty("XML")
+}
+
+func (b *Builder) NewKindProperty() *VCardProperty {
+	return b.newProperty("KIND")
+}
+
+func (b *Builder) NewGenderProperty() *VCardProperty {
+	return b.newProperty("GENDER")
+}
+
+func (b *Builder) NewImppProperty() *VCardProperty {
+	return b.newProperty("IMPP")
+}
+
+func (b *Builder) NewLangProperty() *VCardProperty {
+	return b.newProperty("LANG")
+}
+
+func (b *Builder) NewMemberProperty() *VCardProperty {
+	return b.newProperty("MEMBER")
+}
+
+func (b *Builder) NewClientPidMappProperty() *VCardProperty {
+	return b.newProperty("CLIENTPIDMAP")
+}
+
+/**
+ * builders for a new card of each version
+ */
+type BuilderV21 struct {
+	*Builder
+}
+
+type BuilderV3 struct {
+	*Builder
+}
+
+type BuilderV4 struct {
+	*Builder
+}
+
+var (
+	_ IBuilder = (*BuilderV21)(nil)
+	_ IBuilder = (*BuilderV3)(nil)
+	_ IBuilder = (*BuilderV4)(nil)
+)
+
+func NewBuilderV21() *BuilderV21 {
+	return &BuilderV21{NewBuilder(NewVCardV21())}
+}
+
+func NewBuilderV3() *BuilderV3 {
+	return &BuilderV3{NewBuilder(NewVCardV3())}
+}
+
+func NewBuilderV4() *BuilderV4 {
+	return &BuilderV4{NewBuilder(NewVCardV4())}
+}
@@ -6,6 +6,9 @@
 package vcard
 
 import (
+	"fmt"
+	"io"
+	"sort"
 	"strings"
 )
 
@@ -19,12 +22,113 @@ type Builder struct {
 	 */
 	 addPropertyScenario string
 
+	/**
+	 * order of the parameters in the rendered properties:
+	 *   - insertion - the order in which the parameters were added to the property
+	 *   - alphabetical - sorted by parameter name (canonical form, independent of how the card was built)
+	 *  default: insertion
+	 */
+	parameterOrder string
+
+	// maximum width of the physical lines in octets (see Fold); 0 disables folding. default: 75
+	foldWidth int
+
+	// line break written between the lines: "\r\n" (default) or "\n"
+	lineEnding string
+
+	// the inline PHOTO and LOGO images are reduced with these options; nil keeps the images
+	thumbnailOptions *ThumbnailOptions
+
+	// the images that could not be reduced during the last Build / WriteTo
+	thumbnailErrors []error
+
 	// vcard string
 	cardString strings.Builder
 }
 
 
 
+/**
+ * set the order of the rendered parameters: insertion or alphabetical; other values are ignored
+ */
+func (b *Builder) SetParameterOrder(v string) {
+	switch (v) {
+		case "insertion", "alphabetical":
+			b.parameterOrder = v
+	}
+}
+
+func (b *Builder) GetParameterOrder() string {
+	if b.parameterOrder == "" {
+		return "insertion"
+	}
+	return b.parameterOrder
+}
+
+/**
+ * set the maximum width of the physical lines, in octets; 0 (or a negative value) disables folding
+ */
+func (b *Builder) SetFoldWidth(v int) {
+	if v < 0 {
+		v = 0
+	}
+	b.foldWidth = v
+}
+
+func (b *Builder) GetFoldWidth() int {
+	return b.foldWidth
+}
+
+/**
+ * set the line break: "\r\n" (required by the RFCs) or "\n"; other values are ignored
+ */
+func (b *Builder) SetLineEnding(v string) {
+	switch (v) {
+		case "\r\n", "\n":
+			b.lineEnding = v
+	}
+}
+
+func (b *Builder) GetLineEnding() string {
+	if b.lineEnding == "" {
+		return "\r\n"
+	}
+	return b.lineEnding
+}
+
+/**
+ * reduce the inline PHOTO and LOGO images when the card is rendered (see MediaValue.Thumbnail); nil keeps the images
+ * NewBuilder takes the options of the card (see IVCard.SetThumbnailOptions)
+ * the images keep their format (Format is ignored) so that their TYPE and MEDIATYPE parameters stay right;
+ * a property with an image that can not be reduced is not written (see GetThumbnailErrors)
+ */
+func (b *Builder) SetThumbnailOptions(o *ThumbnailOptions) {
+	if o != nil {
+		c := *o
+		c.Format = ""
+		o = &c
+	}
+	b.thumbnailOptions = o
+}
+
+func (b *Builder) GetThumbnailOptions() *ThumbnailOptions {
+	return b.thumbnailOptions
+}
+
+/**
+ * the errors of the images that could not be reduced during the last Build or WriteTo; their properties were not written
+ */
+func (b *Builder) GetThumbnailErrors() []error {
+	return b.thumbnailErrors
+}
+
+/**
+ * validate the card (see Validate); true if there is no error, the warnings are ignored
+ */
+func (b *Builder) Validate() bool {
+	return IsValid(Validate(b.vcard))
+}
+
 func (b *Builder) GetString() string {
 	return b.cardString.String()
 }
@@ -34,56 +138,191 @@ func (b *Builder) Build() string {
 	// reset the string
 	b.cardString.Reset() // b.cardString = strings.Builder{}
 
-	// write begin property
-	b.cardString.WriteString(b.RenderProperty(b.vcard.CreateProperty("begin")))
-	b.cardString.WriteString("\r\n")
+	// writing to a strings.Builder never fails
+	b.WriteTo(&b.cardString)
 
-	// write version property
-	b.cardString.WriteString(b.RenderProperty(b.vcard.CreateProperty("version")))
-	b.cardString.WriteString("\r\n")
+	return b.GetString()
+}
+
+/**
+ * write the card to w, one property at a time: the physical lines are written to w as they are folded,
+ * only the value of the current property is kept in memory
+ * as Build, the output ends with END:VCARD without a line break
+ */
+func (b *Builder) WriteTo(w io.Writer) (int64, error) {
+	b.thumbnailErrors = nil
+	f := newFoldWriter(w, b.foldWidth, b.GetLineEnding())
+
+	// write begin and version properties
+	for _, name := range []string{"begin", "version"} {
+		b.writeProperty(f, b.vcard.CreateProperty(name))
+		f.newLine()
+	}
 
 	for _, p := range b.vcard.GetProperties() {
+		if f.err != nil {
+			return f.written, f.err
+		}
 		switch p.GetName() {
 			case "BEGIN", "END", "VERSION":
 				// these properties are manually added in the correct order
 				continue
-			default:
-				// render a property
-				b.cardString.WriteString(b.RenderProperty(p))
-				b.cardString.WriteString("\r\n")
+		}
+		// a property with an image that can not be reduced is dropped
+		if b.writeProperty(f, p) {
+			f.newLine()
 		}
 	}
 
 	// write end property
-	b.cardString.WriteString(b.RenderProperty(b.vcard.CreateProperty("end")))
-
-	return b.GetString()
+	b.writeProperty(f, b.vcard.CreateProperty("end"))
+	return f.written, f.err
 }
 
 /**
- * render a property
+ * render a property, folded
+ * the values of the binary properties (PHOTO, LOGO, SOUND, KEY) start on a new line
  */
 
 func (b *Builder) RenderProperty(p IProperty) string {
 	var s strings.Builder
+	b.writeProperty(newFoldWriter(&s, b.foldWidth, b.GetLineEnding()), p)
+	return s.String()
+}
 
-	s.WriteString(p.GetName())
-	s.WriteString(b.RenderParameters(p.GetParameters()))
-	s.WriteString(":")
+/**
+ * write a property, without the final line break; false if nothing was written because its image
+ * could not be reduced (see thumbnail)
+ */
+func (b *Builder) writeProperty(f *foldWriter, p IProperty) bool {
+	// the value is rendered first: a failed thumbnail drops the property
+	failed := len(b.thumbnailErrors)
+	value := b.RenderPropertyValue(p)
+	if len(b.thumbnailErrors) > failed {
+		return false
+	}
 
+	if b.vcard.GetVersion() == "2.1" {
+		b.writePropertyV21(f, p, escapedToV21(value))
+		return true
+	}
+
+	f.fold(renderName(p))
+	f.fold(b.RenderParameters(p.GetParameters()))
+	f.fold(b.RenderParameters(impliedParameters(p, b.vcard.GetVersion())))
+	f.fold(":")
 
-	if (p.GetName() == "PHOTO") {
+	if isBinaryProperty(p.GetName()) && b.foldWidth > 0 && value != "" {
 		// be sure that the value is on a new line
-		var  r strings.Builder
-		r.WriteString(FormatLine(s.String()))
-		r.WriteString(FormatSecondaryLines(b.RenderPropertyValue(p)))
-		return r.String()
+		f.continueLine()
+	}
+	f.fold(value)
+	return true
+}
 
-	} else {
-		s.WriteString(b.RenderPropertyValue(p))
-		return FormatLine(s.String())
+/**
+ * name of a property with its group: item1.EMAIL
+ */
+func renderName(p IProperty) string {
+	if p.GetGroup() != "" {
+		return p.GetGroup() + "." + p.GetName()
+	}
+	return p.GetName()
+}
+
+/**
+ * parameters required by the value of a property that are missing in the property:
+ *  - the tel uris of vcard 4.0 (TEL;VALUE=uri:tel:+1...)
+ *  - the inline media of vcard 2.1 / 3.0 (PHOTO;ENCODING=b;TYPE=JPEG:...) and their uris (PHOTO;VALUE=uri:...)
+ *  - the media type of the vcard 4.0 media uris (PHOTO;MEDIATYPE=image/jpeg:http://...)
+ */
+func impliedParameters(p IProperty, version string) []IParameter {
+	var result []IParameter
+	add := func(name string, value string) {
+		if p.GetParameter(name) == nil {
+			param := NewParameter(name)
+			param.AddValue(value)
+			result = append(result, param)
+		}
 	}
 
+	switch v := p.GetFirstValue().(type) {
+		case *TelValue:
+			if version == "4.0" && v.IsGlobal() {
+				add("VALUE", "uri")
+			}
+		case *MediaValue:
+			inline := v.IsB64Encoded && !v.IsUrl
+			switch {
+				case version == "4.0":
+					if v.IsUrl && v.MediaType != "" {
+						add("MEDIATYPE", v.MediaType)
+					}
+				case inline:
+					if version == "2.1" {
+						add("ENCODING", "BASE64")
+					} else {
+						add("ENCODING", "b")
+					}
+					if v.MediaType != "" {
+						add("TYPE", typeFromMediaType(v.MediaType))
+					}
+				case v.IsUrl:
+					if version == "2.1" {
+						add("VALUE", "URL")
+					} else {
+						add("VALUE", "uri")
+					}
+			}
+	}
+	return result
+}
+
+/**
+ * write a vcard 2.1 property
+ *  - BASE64 values start on a new line, are folded and followed by an empty line
+ *  - values with line breaks or non ascii chars are written as QUOTED-PRINTABLE utf-8 text
+ *  - other lines are not folded (vcard 2.1 allows folding only before a white space)
+ */
+func (b *Builder) writePropertyV21(f *foldWriter, p IProperty, value string) {
+	var encoding string
+
+	implied := impliedParameters(p, "2.1")
+	for _, param := range append(append([]IParameter(nil), p.GetParameters()...), implied...) {
+		if param.GetName() == "ENCODING" && len(param.GetValue()) > 0 {
+			encoding = strings.ToUpper(param.GetValue()[0])
+		}
+	}
+
+	f.write(renderName(p))
+	f.write(b.RenderParameters(p.GetParameters()))
+	f.write(b.RenderParameters(implied))
+
+	switch encoding {
+		case "BASE64":
+			f.write(":")
+			if b.foldWidth > 0 && value != "" {
+				f.continueLine()
+			}
+			f.fold(value)
+			f.newLine()
+			return
+		case "":
+			if needsQuotedPrintable(value) {
+				encoding = "QUOTED-PRINTABLE"
+				f.write(";ENCODING=QUOTED-PRINTABLE")
+				if p.GetParameter("CHARSET") == nil {
+					f.write(";CHARSET=UTF-8")
+				}
+			}
+	}
+
+	f.write(":")
+	if encoding == "QUOTED-PRINTABLE" {
+		f.write(encodeQuotedPrintable(value, f.col, b.GetLineEnding()))
+	} else {
+		f.write(value)
+	}
 }
 
 func (b *Builder) RenderPropertyValue(p IProperty) string {
@@ -93,18 +332,62 @@ func (b *Builder) RenderPropertyValue(p IProperty) string {
 		if idx > 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(v.GetString())
+		s.WriteString(renderValue(b.thumbnail(p, v), b.vcard.GetVersion()))
 	}
 
 	return s.String()
 }
 
 
+/**
+ * reduced inline image of a PHOTO or LOGO property (see SetThumbnailOptions)
+ * on error the image is returned as it is and the error is kept in thumbnailErrors: writeProperty then drops the property
+ */
+func (b *Builder) thumbnail(p IProperty, v IData) IData {
+	if b.thumbnailOptions == nil || (p.GetName() != "PHOTO" && p.GetName() != "LOGO") {
+		return v
+	}
+	m, ok := v.(*MediaValue)
+	if !ok || !m.IsB64Encoded || m.IsUrl {
+		return v
+	}
+	t, err := m.Thumbnail(*b.thumbnailOptions)
+	if err != nil {
+		b.thumbnailErrors = append(b.thumbnailErrors, fmt.Errorf("vcard: %s: %w", p.GetName(), err))
+		return v
+	}
+	return t
+}
+
+/**
+ * text of a value for a version: RFC 2426 escapes the semicolons of the text values, RFC 6350 only those of the structured values
+ */
+func renderValue(v IData, version string) string {
+	switch d := v.(type) {
+		case IVersionedData:
+			return d.GetVersionedString(version)
+		case *TextValue, *EmailValue:
+			if version == "3.0" {
+				return EscapeComponent(d.GetValue())
+			}
+	}
+	return v.GetString()
+}
+
 /**
  * render property' parameters
  */
-func (b *Builder) RenderParameters(parameters map[string]IParameter) string {
+func (b *Builder) RenderParameters(parameters []IParameter) string {
 	var s strings.Builder
+
+	if b.GetParameterOrder() == "alphabetical" {
+		// sort a copy, the property keeps its own order
+		parameters = append([]IParameter(nil), parameters...)
+		sort.SliceStable(parameters, func(i, j int) bool {
+			return parameters[i].GetName() < parameters[j].GetName()
+		})
+	}
+
 	for _, p := range parameters {
 		s.WriteString(";")
 		s.WriteString(b.RenderParameter(p))
@@ -121,6 +404,17 @@ func (b *Builder) RenderParameter(p IParameter) string {
 		s strings.Builder
 	)
 
+	if p.GetName() == "TYPE" && b.vcard.GetVersion() == "2.1" {
+		// vcard 2.1 types are written without the parameter name: TEL;HOME;VOICE:...
+		for i, pv := range p.GetValue() {
+			if i > 0 {
+				s.WriteString(";")
+			}
+			s.WriteString(pv)
+		}
+		return s.String()
+	}
+
 	// @TODO: render parameters
 	s.WriteString(p.GetName())
 	s.WriteString("=")
@@ -128,9 +422,11 @@ func (b *Builder) RenderParameter(p IParameter) string {
 	firstWrite := true
 
 	for _, pv := range p.GetValue() {
-		if strings.Contains(pv, "\"") {
-			// param values must not contains "
-			continue
+		// param values must not contains " or line breaks
+		if b.vcard.GetVersion() == "2.1" {
+			pv = parameterValueV21(pv)
+		} else {
+			pv = EscapeParameterValue(pv)
 		}
 
 		if !firstWrite {
@@ -154,112 +450,65 @@ func (b *Builder) RenderParameter(p IParameter) string {
 
 
 /**
- *  Content lines  SHOULD be folded to a maximum width of 75 octets, excluding the line
- *  break.  Multi-octet characters MUST remain contiguous.
- * A logical line MAY be continued on the next physical line anywhere
- *  between two characters by inserting a CRLF immediately followed by a
- *  single white space character (space (U+0020) or horizontal tab
- *  (U+0009))
+ * vcard 2.1 has no encoding for the parameter values (RFC 6868 is for vcard 3.0 and 4.0):
+ * the double quotes are dropped and the line breaks replaced with spaces
  */
-
- /*
-func FormatLine(s string) string {
-	var (
-		rLine strings.Builder
-		line strings.Builder
-	)
-	line.Grow(75)
-
-	if (forceAsSecondaryLine) {
-		rLine.WriteString("\r\n ")
-	}
-
-	saveLine := false
-	for i, r := range s {
-		if line.Len() + len(string(r)) <= 75 {
-			line.WriteRune(r)
-		} else {
-			saveLine = true
-		}
-
-		if saveLine || (i + len(string(r)) == len(s)) {
-			// we have 75 chars or is the last char to save
-			if  rLine.Len() > 0 {
-				rLine.WriteString("\r\n ")
-			}
-			rLine.Grow(len(line.String()))
-			rLine.WriteString(line.String())
-
-			saveLine = false
-			line.Reset()
-		}
+func parameterValueV21(v string) string {
+	if !strings.ContainsAny(v, "\r\n\"") {
+		return v
 	}
-	return rLine.String()
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ", "\"", "").Replace(v)
 }
-*/
-
-func FormatLine(s string) string {
-	var (
-		result, line strings.Builder
-		i int
-		r rune
-	)
 
-	for i, r = range s {
-		if line.Len() + len(string(r)) <= 73 {
-			line.WriteRune(r)
-		} else {
-			// we finished first line
-			break
-		}
-	}
-	result.WriteString(line.String())
-	if line.Len() < len(s) {
-		result.WriteString(FormatSecondaryLines(s[i:]))
+/**
+ * RFC 6868 parameter value encoding (vcard 3.0 and 4.0): ^ -> ^^, new line (CRLF, LF or CR) -> ^n, " -> ^'
+ */
+func EscapeParameterValue(v string) string {
+	if !strings.ContainsAny(v, "^\r\n\"") {
+		return v
 	}
-
-	return result.String()
+	return parameterValueEscaper.Replace(v)
 }
 
-func FormatSecondaryLines(s string) string {
-	var lines strings.Builder
-	var currentLine strings.Builder
+var parameterValueEscaper = strings.NewReplacer("^", "^^", "\r\n", "^n", "\r", "^n", "\n", "^n", "\"", "^'")
 
-	if len(s) == 0 {
-		return ""
+/**
+ * reverse of EscapeParameterValue; a ^ not followed by ^, n or ' is kept as it is
+ */
+func UnescapeParameterValue(v string) string {
+	if !strings.Contains(v, "^") {
+		return v
 	}
 
-	saveLine := false
-	currentLine.WriteString("\r\n ")
-	for i, r := range s {
-		if currentLine.Len() - 2 + len(string(r)) <= 73 {
-			currentLine.WriteRune(r)
-		} else {
-			saveLine = true
-		}
-
-
-		if saveLine || (i + len(string(r)) == len(s)) {
-			// we have max 75 chars or it's the end of string
-			lines.Grow(len(currentLine.String()))
-			lines.WriteString(currentLine.String())
-			currentLine.Reset()
-
-			// write current character
-			currentLine.WriteString("\r\n ")
-			currentLine.WriteRune(r)
-			saveLine = false
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '^' && i+1 < len(v) {
+			switch (v[i+1]) {
+				case '^':
+					s.WriteByte('^')
+					i++
+					continue
+				case 'n':
+					s.WriteByte('\n')
+					i++
+					continue
+				case '\'':
+					s.WriteByte('"')
+					i++
+					continue
+			}
 		}
+		s.WriteByte(v[i])
 	}
-
-	return lines.String()
+	return s.String()
 }
 
-
 func NewBuilder(vc IVCard) *Builder {
 	b := Builder{
 		vcard: vc,
+		foldWidth: DefaultFoldWidth,
 	}
+	b.SetThumbnailOptions(vc.GetThumbnailOptions())
 
 	return &b
 }
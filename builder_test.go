@@ -0,0 +1,167 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+/**
+ * records the size of the largest write
+ */
+type recordingWriter struct {
+	buf bytes.Buffer
+	largest int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.largest {
+		w.largest = len(p)
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteToStreamsFoldedLines(t *testing.T) {
+	for _, version := range []string{"2.1", "3.0", "4.0"} {
+		card, _ := NewVCard(version)
+		fn := card.CreateProperty("FN")
+		fn.AddValue(NewText("John"))
+		card.AddProperty(fn)
+		photo := card.CreateProperty("PHOTO")
+		photo.AddValue(NewMediaFromBytes(bytes.Repeat([]byte{0xff, 0xd8, 0xff}, 10000), "image/jpeg"))
+		card.AddProperty(photo)
+
+		b := NewBuilder(card)
+		var w recordingWriter
+		n, err := b.WriteTo(&w)
+		if err != nil || n != int64(w.buf.Len()) {
+			t.Errorf("%s: WriteTo returns %d, %v for %d octets", version, n, err, w.buf.Len())
+		}
+		if w.buf.String() != b.Build() {
+			t.Errorf("%s: WriteTo and Build differ", version)
+		}
+		if w.largest > DefaultFoldWidth {
+			t.Errorf("%s: write of %d octets, the folded lines are not streamed", version, w.largest)
+		}
+	}
+}
+
+func TestBuilderPropertyCreators(t *testing.T) {
+	if p := NewBuilderV4().NewAgentProperty(); p != nil {
+		t.Errorf("vcard 4.0 AGENT is created: %v", p.GetName())
+	}
+	if p := NewBuilderV3().NewKindProperty(); p != nil {
+		t.Errorf("vcard 3.0 KIND is created: %v", p.GetName())
+	}
+	if p := NewBuilderV4().NewCustomProperty("foo"); p == nil || p.GetName() != "X-FOO" {
+		t.Errorf("extension property: %v", p)
+	}
+
+	tel := NewBuilderV4().NewTelProperty()
+	if tel == nil || tel.GetCardinality() != "*" || tel.GetValueType() != "text" {
+		t.Fatalf("vcard 4.0 TEL: %+v", tel)
+	}
+	if !tel.AcceptsParameter("type") || !tel.AcceptsParameter("PREF") || !tel.AcceptsParameter("X-SERVICE") {
+		t.Errorf("TEL does not accept its parameters: %v", tel.GetAcceptedParameters())
+	}
+	if tel.AcceptsParameter("ENCODING") {
+		t.Errorf("vcard 4.0 TEL accepts ENCODING")
+	}
+	if NewBuilderV4().NewVersionProperty().AcceptsParameter("TYPE") {
+		t.Errorf("VERSION accepts TYPE")
+	}
+}
+
+func TestStrictPropertyRejectsParameters(t *testing.T) {
+	card := NewVCardV4()
+	card.SetStrict(true)
+	b := NewBuilder(card)
+	bday := b.NewBDayProperty()
+	b.AddPropertyParameter(bday, "MEDIATYPE", []string{"text/plain"})
+	b.AddPropertyParameter(bday, "CALSCALE", []string{"gregorian"})
+	b.AddPropertyParameter(bday, "X-SOURCE", []string{"import"})
+
+	if bday.GetParameter("MEDIATYPE") != nil {
+		t.Errorf("strict BDAY accepts MEDIATYPE")
+	}
+	if len(bday.GetParameterErrors()) != 1 {
+		t.Errorf("parameter errors: %v", bday.GetParameterErrors())
+	}
+	if bday.GetParameter("CALSCALE") == nil || bday.GetParameter("X-SOURCE") == nil {
+		t.Errorf("strict BDAY rejects its parameters")
+	}
+
+	// without strict mode the parameters are kept (the parsed cards are not changed), Validate reports them
+	parsed, err := Parse("BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\r\nBDAY;MEDIATYPE=text/plain:19850412\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.GetProperty("BDAY")[0].GetParameter("MEDIATYPE") == nil {
+		t.Errorf("the parsed parameter is dropped")
+	}
+}
+
+func TestEscapeParameterValueLineBreaks(t *testing.T) {
+	tests := map[string]string{
+		"a\r\nb": "a^nb",
+		"a\nb": "a^nb",
+		"a\rb": "a^nb",
+		"a\r\rb": "a^n^nb",
+		"a^\"b\"": "a^^^'b^'",
+	}
+	for in, want := range tests {
+		if got := EscapeParameterValue(in); got != want {
+			t.Errorf("EscapeParameterValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	card := NewVCardV4()
+	fn := card.CreateProperty("FN")
+	fn.AddValue(NewText("John"))
+	card.AddPropertyParameter(fn, "X-NOTE", []string{"a\rb"})
+	card.AddProperty(fn)
+	if out := card.Build(); strings.Count(out, "\r") != strings.Count(out, "\r\n") || !strings.Contains(out, "X-NOTE=a^nb") {
+		t.Errorf("lone CR: %q", out)
+	}
+}
+
+func TestParameterOrder(t *testing.T) {
+	card := NewVCardV3()
+	tel := card.CreateProperty("TEL")
+	tel.AddValue(NewText("+1 555 0100"))
+	card.AddPropertyParameter(tel, "X-SOURCE", []string{"import"})
+	card.AddPropertyParameter(tel, "TYPE", []string{"work"})
+	card.AddPropertyParameter(tel, "X-ACCOUNT", []string{"main"})
+	card.AddPropertyParameter(tel, "TYPE", []string{"voice"})
+	card.AddProperty(tel)
+
+	var names []string
+	for _, p := range tel.GetParameters() {
+		names = append(names, p.GetName())
+	}
+	if strings.Join(names, ",") != "X-SOURCE,TYPE,X-ACCOUNT" {
+		t.Errorf("parameters %v, want the insertion order", names)
+	}
+
+	b := NewBuilder(card)
+	first := b.RenderProperty(tel)
+	if first != "TEL;X-SOURCE=import;TYPE=work,voice;X-ACCOUNT=main:+1 555 0100" {
+		t.Errorf("insertion order: %q", first)
+	}
+	for i := 0; i < 20; i++ {
+		if b.RenderProperty(tel) != first {
+			t.Fatalf("the output changes between runs")
+		}
+	}
+
+	b.SetParameterOrder("alphabetical")
+	if out := b.RenderProperty(tel); out != "TEL;TYPE=work,voice;X-ACCOUNT=main;X-SOURCE=import:+1 555 0100" {
+		t.Errorf("alphabetical order: %q", out)
+	}
+
+	// SetParameters keeps the given order
+	tel.SetParameters([]IParameter{tel.GetParameter("X-ACCOUNT"), tel.GetParameter("TYPE")})
+	if out := NewBuilder(card).RenderProperty(tel); out != "TEL;X-ACCOUNT=main;TYPE=work,voice:+1 555 0100" {
+		t.Errorf("SetParameters order: %q", out)
+	}
+}
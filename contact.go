@@ -0,0 +1,528 @@
+/**
+ * typed access to the common properties of a card
+ * a Contact has no state of its own: every accessor reads the properties of the card and every setter updates them,
+ * so the properties and parameters that are not modeled (PID, ALTID, X-...) are kept on round-trip
+ */
+package vcard
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Contact struct {
+	card IVCard
+}
+
+/**
+ * EMAIL property
+ */
+type Email struct {
+	Address string
+
+	// TYPE values, lower case (ex: "work", "internet"); the "pref" type is reported by Pref
+	Types []string
+
+	// preference between 1 (most preferred) and 100; 0 if not set
+	// vcard 2.1 and 3.0 have no preference level: TYPE=pref is reported as 1
+	Pref int
+}
+
+/**
+ * key to compare the addresses (see EmailValue.ComparisonKey); empty if it is not a valid address
+ */
+func (e Email) ComparisonKey() string {
+	if v, err := ParseEmail(e.Address); err == nil {
+		return v.ComparisonKey()
+	}
+	return ""
+}
+
+/**
+ * TEL property
+ */
+type Phone struct {
+	// number as text (+15551234567), without the tel: prefix
+	Number string
+
+	// extension digits; empty if none
+	Extension string
+
+	Types []string
+	Pref int
+}
+
+/**
+ * E.164 form of the number (see TelValue.E164); empty if it is not a global number
+ */
+func (t Phone) E164() string {
+	if v, err := ParseTel(t.Number); err == nil {
+		return v.E164()
+	}
+	return ""
+}
+
+/**
+ * ADR property
+ */
+type Address struct {
+	Pobox string
+	Ext string
+	Street string
+	Locality string
+	Region string
+	PostalCode string
+	Country string
+	Types []string
+	Pref int
+}
+
+/**
+ * typed view of a card; the changes are made on the card
+ */
+func NewContact(card IVCard) *Contact {
+	return &Contact{
+		card: card,
+	}
+}
+
+func (c *Contact) GetVCard() IVCard {
+	return c.card
+}
+
+/**
+ * FN property; empty if missing
+ */
+func (c *Contact) FormattedName() string {
+	if p := c.firstProperty("FN"); p != nil && p.GetFirstValue() != nil {
+		return p.GetFirstValue().GetValue()
+	}
+	return ""
+}
+
+/**
+ * FN in the best of the preferred languages (see BestAlternative); empty if missing
+ */
+func (c *Contact) FormattedNameIn(preferred ...string) string {
+	if p := BestAlternative(c.card.GetProperty("FN"), preferred); p != nil && p.GetFirstValue() != nil {
+		return p.GetFirstValue().GetValue()
+	}
+	return ""
+}
+
+func (c *Contact) SetFormattedName(s string) {
+	c.setSingle("FN", NewText(s))
+}
+
+/**
+ * N property; nil if missing
+ */
+func (c *Contact) Name() *NameValue {
+	if p := c.firstProperty("N"); p != nil {
+		if n, ok := p.GetFirstValue().(*NameValue); ok {
+			return n
+		}
+	}
+	return nil
+}
+
+/**
+ * N in the best of the preferred languages (see BestAlternative); nil if missing
+ */
+func (c *Contact) NameIn(preferred ...string) *NameValue {
+	if alternatives := GetAlternatives(c.card, "N"); len(alternatives) > 0 {
+		if n, ok := BestAlternative(alternatives[0], preferred).GetFirstValue().(*NameValue); ok {
+			return n
+		}
+	}
+	return nil
+}
+
+func (c *Contact) SetName(n *NameValue) {
+	c.setSingle("N", n)
+}
+
+/**
+ * ORG property; nil if missing
+ */
+func (c *Contact) Organization() *OrganizationValue {
+	if p := c.firstProperty("ORG"); p != nil {
+		if o, ok := p.GetFirstValue().(*OrganizationValue); ok {
+			return o
+		}
+	}
+	return nil
+}
+
+func (c *Contact) SetOrganization(o *OrganizationValue) {
+	c.setSingle("ORG", o)
+}
+
+/**
+ * BDAY property; false if missing or if it is not a complete date (ex: --0415, text values)
+ */
+func (c *Contact) Birthday() (time.Time, bool) {
+	p := c.firstProperty("BDAY")
+	if p == nil || p.GetFirstValue() == nil {
+		return time.Time{}, false
+	}
+	return timeOf(p.GetFirstValue())
+}
+
+/**
+ * set BDAY to a date, or to a date-time when t has a time of day
+ */
+func (c *Contact) SetBirthday(t time.Time) {
+	d := dateValueOf(t)
+	c.setSingle("BDAY", d)
+	p := c.firstProperty("BDAY")
+	// a previous text value
+	p.DeleteParameter("VALUE")
+	if _, ok := d.(*DateTimeValue); ok && defaultValueType("BDAY", c.card.GetVersion()) == "date" {
+		c.card.AddPropertyParameter(p, "VALUE", []string{"date-time"})
+	}
+}
+
+/**
+ * PHOTO property; nil if missing
+ */
+func (c *Contact) Photo() *MediaValue {
+	if p := c.firstProperty("PHOTO"); p != nil {
+		if v, ok := p.GetFirstValue().(*MediaValue); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+/**
+ * set the PHOTO property; the inline images are written as data uris (4.0) or with the ENCODING and TYPE parameters (2.1, 3.0)
+ */
+func (c *Contact) SetPhoto(v *MediaValue) {
+	v = copyValue(v).(*MediaValue)
+	c.setSingle("PHOTO", v)
+	p := c.firstProperty("PHOTO")
+	for _, name := range []string{"ENCODING", "TYPE", "MEDIATYPE", "VALUE"} {
+		p.DeleteParameter(name)
+	}
+
+	inline := v.IsB64Encoded && !v.IsUrl
+	switch (c.card.GetVersion()) {
+		case "4.0":
+			if inline {
+				v.IsDataUri = true
+			} else if v.MediaType != "" && !v.IsDataUri {
+				c.card.AddPropertyParameter(p, "MEDIATYPE", []string{v.MediaType})
+			}
+			return
+		case "2.1":
+			if inline || v.IsDataUri {
+				c.card.AddPropertyParameter(p, "ENCODING", []string{"BASE64"})
+			} else if v.IsUrl {
+				c.card.AddPropertyParameter(p, "VALUE", []string{"url"})
+			}
+		default:
+			if inline || v.IsDataUri {
+				c.card.AddPropertyParameter(p, "ENCODING", []string{"b"})
+			} else if v.IsUrl {
+				c.card.AddPropertyParameter(p, "VALUE", []string{"uri"})
+			}
+	}
+	v.IsDataUri = false
+	if v.MediaType != "" {
+		c.card.AddPropertyParameter(p, "TYPE", []string{typeFromMediaType(v.MediaType)})
+	}
+}
+
+/**
+ * EMAIL properties, in the order of the card
+ */
+func (c *Contact) Emails() []Email {
+	var result []Email
+	for _, p := range c.card.GetProperty("EMAIL") {
+		e := Email{
+			Types: c.types(p),
+			Pref: c.pref(p),
+		}
+		if d := p.GetFirstValue(); d != nil {
+			e.Address = d.GetValue()
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+/**
+ * replace the EMAIL properties; the existing properties are updated in order, so their other parameters are kept
+ */
+func (c *Contact) SetEmails(emails []Email) {
+	var values []contactValue
+	for _, e := range emails {
+		values = append(values, contactValue{emailData(e.Address), e.Types, e.Pref})
+	}
+	c.setMultiple("EMAIL", values)
+}
+
+/**
+ * the bare address of a valid address ("Jane <jane@example.com>" -> jane@example.com), the text otherwise
+ */
+func emailData(address string) IData {
+	if v, err := ParseEmail(address); err == nil {
+		return v
+	}
+	return NewText(address)
+}
+
+func (c *Contact) AddEmail(e Email) {
+	c.SetEmails(append(c.Emails(), e))
+}
+
+/**
+ * TEL properties, in the order of the card
+ */
+func (c *Contact) Phones() []Phone {
+	var result []Phone
+	for _, p := range c.card.GetProperty("TEL") {
+		t := Phone{
+			Types: c.types(p),
+			Pref: c.pref(p),
+		}
+		switch d := p.GetFirstValue().(type) {
+			case nil:
+			case *TelValue:
+				if d.Validate() {
+					t.Number, t.Extension = d.Number, d.Extension
+				} else {
+					t.Number = d.Text()
+				}
+			default:
+				t.Number = d.GetValue()
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+/**
+ * replace the TEL properties; the existing properties are updated in order, so their other parameters are kept
+ */
+func (c *Contact) SetPhones(phones []Phone) {
+	var values []contactValue
+	for _, t := range phones {
+		values = append(values, contactValue{telData(t.Number, t.Extension), t.Types, t.Pref})
+	}
+	c.setMultiple("TEL", values)
+}
+
+/**
+ * a TelValue for a valid number (written as a tel uri in vcard 4.0), the text otherwise
+ */
+func telData(number string, extension string) IData {
+	if v, err := ParseTel(number); err == nil {
+		if extension != "" {
+			v.Extension = extension
+		}
+		if v.Validate() {
+			return v
+		}
+	}
+	if extension != "" {
+		number += " ext. " + extension
+	}
+	return NewText(number)
+}
+
+func (c *Contact) AddPhone(t Phone) {
+	c.SetPhones(append(c.Phones(), t))
+}
+
+/**
+ * ADR properties, in the order of the card
+ */
+func (c *Contact) Addresses() []Address {
+	var result []Address
+	for _, p := range c.card.GetProperty("ADR") {
+		a := Address{
+			Types: c.types(p),
+			Pref: c.pref(p),
+		}
+		if v, ok := p.GetFirstValue().(*AddressValue); ok {
+			a.Pobox = v.Pobox
+			a.Ext = v.Ext
+			a.Street = v.Street
+			a.Locality = v.Locality
+			a.Region = v.Region
+			a.PostalCode = v.PostalCode
+			a.Country = v.Country
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+func (c *Contact) SetAddresses(addresses []Address) {
+	var values []contactValue
+	for _, a := range addresses {
+		v := NewAddress()
+		v.Pobox = a.Pobox
+		v.Ext = a.Ext
+		v.Street = a.Street
+		v.Locality = a.Locality
+		v.Region = a.Region
+		v.PostalCode = a.PostalCode
+		v.Country = a.Country
+		values = append(values, contactValue{v, a.Types, a.Pref})
+	}
+	c.setMultiple("ADR", values)
+}
+
+func (c *Contact) AddAddress(a Address) {
+	c.SetAddresses(append(c.Addresses(), a))
+}
+
+/**
+ * value of a property with TYPE and PREF (EMAIL, TEL, ADR)
+ */
+type contactValue struct {
+	value IData
+	types []string
+	pref int
+}
+
+/**
+ * card that can delete a single property (see baseVCard)
+ */
+type propertyRemover interface {
+	removeProperty(p IProperty)
+}
+
+func (c *Contact) firstProperty(name string) IProperty {
+	if props := c.card.GetProperty(name); len(props) > 0 {
+		return props[0]
+	}
+	return nil
+}
+
+/**
+ * set the value of a single property, created if missing
+ */
+func (c *Contact) setSingle(name string, v IData) {
+	if p := c.firstProperty(name); p != nil {
+		p.SetValue([]IData{v})
+		return
+	}
+	p := c.card.CreateProperty(name)
+	p.SetValue([]IData{v})
+	c.card.AddProperty(p)
+}
+
+/**
+ * replace the values of a multiple property: the first properties are updated, the missing ones are created
+ * and the extra ones are removed
+ */
+func (c *Contact) setMultiple(name string, values []contactValue) {
+	existing := c.card.GetProperty(name)
+	if len(existing) > len(values) {
+		if vc, ok := c.card.(propertyRemover); ok {
+			for _, p := range existing[len(values):] {
+				vc.removeProperty(p)
+			}
+		} else {
+			c.card.DeleteProperty(name)
+			for _, p := range existing[:len(values)] {
+				c.card.AddProperty(p)
+			}
+		}
+		existing = existing[:len(values)]
+	}
+
+	for i, v := range values {
+		var p IProperty
+		if i < len(existing) {
+			p = existing[i]
+		} else {
+			p = c.card.CreateProperty(name)
+		}
+		p.SetValue([]IData{v.value})
+		c.setTypesAndPref(p, v.types, v.pref)
+		if i >= len(existing) {
+			c.card.AddProperty(p)
+		}
+	}
+}
+
+/**
+ * TYPE values of a property, lower case, without "pref"
+ */
+func (c *Contact) types(p IProperty) []string {
+	var result []string
+	if param := p.GetParameter("TYPE"); param != nil {
+		for _, v := range param.GetValue() {
+			v = strings.ToLower(v)
+			if v != "pref" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
+
+/**
+ * PREF parameter (4.0) or TYPE=pref (2.1, 3.0)
+ */
+func (c *Contact) pref(p IProperty) int {
+	if param := p.GetParameter("PREF"); param != nil && len(param.GetValue()) > 0 {
+		if pref, err := strconv.Atoi(param.GetValue()[0]); err == nil {
+			return pref
+		}
+	}
+	if param := p.GetParameter("TYPE"); param != nil {
+		for _, v := range param.GetValue() {
+			if strings.ToLower(v) == "pref" {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+func (c *Contact) setTypesAndPref(p IProperty, types []string, pref int) {
+	p.DeleteParameter("TYPE")
+	p.DeleteParameter("PREF")
+
+	if pref > 0 && c.card.GetVersion() != "4.0" {
+		types = append(append([]string{}, types...), "pref")
+		pref = 0
+	}
+	if len(types) > 0 {
+		c.card.AddPropertyParameter(p, "TYPE", types)
+	}
+	if pref > 0 {
+		c.card.AddPropertyParameter(p, "PREF", []string{strconv.Itoa(pref)})
+	}
+}
+
+/**
+ * time of a date value; the text values are parsed as date-and-or-time
+ */
+func timeOf(d IData) (time.Time, bool) {
+	if v, ok := d.(interface{ Time() (time.Time, bool) }); ok {
+		return v.Time()
+	}
+	v, err := ParseDateAndOrTime(d.GetValue())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return v.Time()
+}
+
+/**
+ * date of t, or date-time in UTC when t has a time of day
+ */
+func dateValueOf(t time.Time) IData {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return NewDate(t)
+	}
+	return NewDateTime(t.UTC())
+}
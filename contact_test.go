@@ -0,0 +1,133 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactPhones(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\r\nTEL;VALUE=uri;TYPE=cell:tel:+15551234567;ext=89\r\nTEL:555 0100\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phones := NewContact(card).Phones()
+	if len(phones) != 2 {
+		t.Fatalf("%d phones", len(phones))
+	}
+	if phones[0].Number != "+15551234567" || phones[0].Extension != "89" || phones[0].E164() != "+15551234567" {
+		t.Errorf("tel uri: %+v", phones[0])
+	}
+	if phones[1].Number != "555 0100" || phones[1].E164() != "" {
+		t.Errorf("text: %+v", phones[1])
+	}
+
+	vc := NewVCardV4()
+	c := NewContact(vc)
+	c.SetPhones([]Phone{{Number: "+1 (555) 123-4567", Extension: "89", Types: []string{"work"}}})
+	ps := vc.GetProperty("TEL")
+	if len(ps) != 1 {
+		t.Fatalf("%d TEL properties", len(ps))
+	}
+	if _, ok := ps[0].GetFirstValue().(*TelValue); !ok {
+		t.Errorf("SetPhones value %T", ps[0].GetFirstValue())
+	}
+	if out := vc.Build(); !strings.Contains(out, "VALUE=uri") || !strings.Contains(out, ":tel:+15551234567;ext=89\r\n") {
+		t.Errorf("vcard 4.0 TEL: %q", out)
+	}
+}
+
+func TestConvertPhonesToV4(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:John\r\nN:;John;;;\r\nTEL;TYPE=CELL:+1 (555) 123-4567\r\nTEL;TYPE=HOME:555 0100\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v4, _, err := Convert(card, "4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := v4.GetProperty("TEL")
+	if len(ps) != 2 {
+		t.Fatalf("%d TEL properties", len(ps))
+	}
+	if _, ok := ps[0].GetFirstValue().(*TelValue); !ok {
+		t.Errorf("global number: value %T", ps[0].GetFirstValue())
+	}
+	if _, ok := ps[1].GetFirstValue().(*TextValue); !ok {
+		t.Errorf("local number: value %T", ps[1].GetFirstValue())
+	}
+
+	out := v4.Build()
+	if !strings.Contains(out, "VALUE=uri") || !strings.Contains(out, ":tel:+15551234567\r\n") {
+		t.Errorf("tel uri: %q", out)
+	}
+	if !strings.Contains(out, ":555 0100\r\n") {
+		t.Errorf("local number: %q", out)
+	}
+}
+
+func TestContactAccessors(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:John Doe\r\n" +
+		"N:Doe;John;;;\r\n" +
+		"ORG:Example;Research\r\n" +
+		"BDAY:1985-04-12\r\n" +
+		"EMAIL;TYPE=internet,work,pref;X-SOURCE=crm:john@example.com\r\n" +
+		"EMAIL;TYPE=home:john@home.example\r\n" +
+		"ADR;TYPE=work:;;1 Main St;Town;;12345;USA\r\n" +
+		"PHOTO;VALUE=uri:http://example.com/john.jpg\r\n" +
+		"END:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewContact(card)
+
+	if c.FormattedName() != "John Doe" {
+		t.Errorf("FormattedName %q", c.FormattedName())
+	}
+	if n := c.Name(); n == nil || len(n.FamilyName) != 1 || n.FamilyName[0] != "Doe" {
+		t.Errorf("Name %+v", n)
+	}
+	if o := c.Organization(); o == nil || o.Company != "Example" || len(o.Departments) != 1 {
+		t.Errorf("Organization %+v", o)
+	}
+	if bday, ok := c.Birthday(); !ok || !bday.Equal(time.Date(1985, 4, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Birthday %v %v", bday, ok)
+	}
+	if p := c.Photo(); p == nil || !p.IsUrl {
+		t.Errorf("Photo %+v", p)
+	}
+
+	emails := c.Emails()
+	if len(emails) != 2 || emails[0].Address != "john@example.com" || emails[0].Pref != 1 || !reflect.DeepEqual(emails[0].Types, []string{"internet", "work"}) {
+		t.Errorf("Emails %+v", emails)
+	}
+	addresses := c.Addresses()
+	if len(addresses) != 1 || addresses[0].Street != "1 Main St" || addresses[0].PostalCode != "12345" || !reflect.DeepEqual(addresses[0].Types, []string{"work"}) {
+		t.Errorf("Addresses %+v", addresses)
+	}
+
+	// the setters keep the parameters that are not modeled
+	emails[0].Address = "john.doe@example.com"
+	c.SetEmails(emails)
+	c.AddAddress(Address{Street: "2 Side St", Locality: "City", Types: []string{"home"}})
+	c.SetFormattedName("John Q. Doe")
+
+	out := card.Build()
+	for _, want := range []string{
+		"\r\nFN:John Q. Doe\r\n",
+		"\r\nEMAIL;X-SOURCE=crm;TYPE=internet,work,pref:john.doe@example.com\r\n",
+		"\r\nADR;TYPE=home:;;2 Side St;City;;;\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not found in\n%s", want, out)
+		}
+	}
+	if len(c.Addresses()) != 2 || len(c.Emails()) != 2 {
+		t.Errorf("properties after the changes:\n%s", out)
+	}
+}
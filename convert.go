@@ -0,0 +1,632 @@
+/**
+ * conversion between vcard versions (2.1, 3.0, 4.0)
+ */
+package vcard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+ * a property or a parameter that could not be carried over (or was changed) by Convert
+ */
+type ConversionWarning struct {
+	// name of the property in the source card
+	Property string
+
+	// name of the parameter; empty if the warning is about the whole property
+	Parameter string
+
+	Message string
+}
+
+func (w ConversionWarning) String() string {
+	if w.Parameter != "" {
+		return fmt.Sprintf("%s;%s: %s", w.Property, w.Parameter, w.Message)
+	}
+	return fmt.Sprintf("%s: %s", w.Property, w.Message)
+}
+
+/**
+ * convert a card to another version
+ * the properties and parameters are mapped between versions:
+ *  - TYPE=pref (2.1, 3.0) <-> PREF=1 (4.0)
+ *  - inline PHOTO, LOGO, SOUND, KEY (ENCODING=b;TYPE=JPEG) <-> data: uri (4.0); MEDIATYPE <-> TYPE for external uris
+ *  - LABEL <-> ADR;LABEL=
+ *  - AGENT <-> RELATED;TYPE=agent
+ *  - SORT-STRING <-> N;SORT-AS=
+ * properties and parameters that do not exist in the target version are dropped and reported as warnings
+ * a conversion between 2.1 and 4.0 is made through 3.0
+ */
+func Convert(card IVCard, targetVersion string) (IVCard, []ConversionWarning, error) {
+	dst, err := NewVCard(targetVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []ConversionWarning
+	from := card.GetVersion()
+	if (from == "2.1" && targetVersion == "4.0") || (from == "4.0" && targetVersion == "2.1") {
+		card, warnings, err = Convert(card, "3.0")
+		if err != nil {
+			return nil, nil, err
+		}
+		from = "3.0"
+	}
+
+	c := &converter{
+		from: from,
+		to: targetVersion,
+		dst: dst,
+		warnings: warnings,
+		minPref: map[string]int{},
+	}
+	c.convert(card)
+
+	return c.dst, c.warnings, nil
+}
+
+type converter struct {
+	from, to string
+	dst IVCard
+	warnings []ConversionWarning
+
+	// lowest PREF value for each property name of a vcard 4.0 source
+	minPref map[string]int
+
+	// vcard 3.0 LABEL and SORT-STRING properties, attached as parameters after all the properties are converted
+	labels []IProperty
+	sortString IProperty
+}
+
+func (c *converter) warn(property, parameter, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, ConversionWarning{
+		Property: property,
+		Parameter: parameter,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *converter) convert(src IVCard) {
+	if c.from == "4.0" {
+		for _, p := range src.GetProperties() {
+			if pref, ok := prefValue(p); ok {
+				if min, exists := c.minPref[p.GetName()]; !exists || pref < min {
+					c.minPref[p.GetName()] = pref
+				}
+			}
+		}
+	}
+
+	for _, p := range src.GetProperties() {
+		switch p.GetName() {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+		c.convertProperty(p)
+	}
+
+	c.attachLabels()
+	c.attachSortString()
+}
+
+func (c *converter) convertProperty(p IProperty) {
+	name := p.GetName()
+
+	if c.from != c.to {
+		switch {
+			case c.to == "4.0" && name == "LABEL":
+				c.labels = append(c.labels, p)
+				return
+			case c.to == "4.0" && name == "SORT-STRING":
+				c.sortString = p
+				return
+			case c.to == "4.0" && name == "AGENT":
+				name = "RELATED"
+			case c.from == "4.0" && name == "RELATED" && hasParameterValue(p, "TYPE", "agent"):
+				name = "AGENT"
+		}
+
+		// standard properties that are not defined in the target version are dropped
+		// (AGENT, LABEL and SORT-STRING are mapped above)
+		if !isPropertyDefined(c.to, name) {
+			c.warn(p.GetName(), "", "property not defined in vCard %s", c.to)
+			return
+		}
+	}
+
+	np := c.dst.CreateProperty(name)
+	np.SetGroup(p.GetGroup())
+	if np.GetCardinality() == "1" || np.GetCardinality() == "*1" {
+		// the representations of the same property (ALTID) count as one in vcard 4.0
+		for _, existing := range c.dst.GetProperty(name) {
+			if c.to == "4.0" && isAlternative(p, existing) {
+				continue
+			}
+			c.warn(p.GetName(), "", "only one property allowed in vCard %s, the next ones are dropped", c.to)
+			return
+		}
+	}
+
+	values := make([]IData, 0, len(p.GetValue()))
+	for _, v := range p.GetValue() {
+		values = append(values, copyValue(v))
+	}
+	if c.from != c.to && c.to == "4.0" && name == "TEL" && !hasParameterValue(p, "VALUE", "text") {
+		telUris(values)
+	}
+	np.SetValue(values)
+
+	if c.from != c.to {
+		switch name {
+			case "PHOTO", "LOGO", "SOUND", "KEY":
+				c.convertMedia(p, np)
+				c.dst.AddProperty(np)
+				return
+		}
+	}
+
+	c.convertParameters(p, np)
+
+	if c.from != c.to {
+		switch {
+			case name == "RELATED" && p.GetName() == "AGENT":
+				c.dst.AddPropertyParameter(np, "TYPE", []string{"agent"})
+				if !hasParameterValue(p, "VALUE", "uri") {
+					c.dst.AddPropertyParameter(np, "VALUE", []string{"text"})
+					c.warn(p.GetName(), "", "embedded vCard converted to a text value")
+				}
+			case name == "AGENT" && !hasParameterValue(p, "VALUE", "text"):
+				c.dst.AddPropertyParameter(np, "VALUE", []string{"uri"})
+			case name == "ADR" && c.from == "4.0":
+				if label := parameterValues(p, "LABEL"); len(label) > 0 {
+					c.addLabel(np, label[0])
+				}
+			case name == "N" && c.from == "4.0":
+				if sortAs := parameterValues(p, "SORT-AS"); len(sortAs) > 0 {
+					sp := c.dst.CreateProperty("SORT-STRING")
+					sp.AddValue(NewText(sortAs[0]))
+					c.dst.AddProperty(sp)
+				}
+		}
+	}
+
+	c.dst.AddProperty(np)
+}
+
+/**
+ * copy the parameters of p to np, mapping them to the target version
+ */
+func (c *converter) convertParameters(p IProperty, np IProperty) {
+	for _, param := range p.GetParameters() {
+		name := param.GetName()
+		values := append([]string(nil), param.GetValue()...)
+
+		if c.from != c.to {
+			switch name {
+				case "TYPE":
+					values = c.convertTypes(p, values)
+				case "PREF":
+					if c.to != "4.0" {
+						if pref, ok := prefValue(p); ok && pref == c.minPref[p.GetName()] {
+							c.dst.AddPropertyParameter(np, "TYPE", []string{"pref"})
+						}
+						continue
+					}
+				case "ENCODING":
+					switch strings.ToUpper(firstOf(values)) {
+						case "QUOTED-PRINTABLE", "8BIT", "7BIT":
+							// the values are kept decoded
+							continue
+					}
+					if c.to == "4.0" {
+						c.warn(p.GetName(), name, "parameter not defined in vCard 4.0")
+						continue
+					}
+				case "CHARSET":
+					// the values are kept as utf-8, except those of the charsets that are not converted
+					if isUtf8Charset(firstOf(values)) {
+						if c.to != "2.1" {
+							continue
+						}
+					} else if c.to == "4.0" {
+						c.warn(p.GetName(), name, "the value is not converted from the %s charset", firstOf(values))
+						continue
+					}
+				case "VALUE":
+					switch strings.ToLower(firstOf(values)) {
+						case "url":
+							values = []string{"uri"}
+						case "content-id", "cid":
+							c.warn(p.GetName(), name, "content-id values are not supported in vCard %s", c.to)
+							continue
+						case "binary":
+							if c.to == "4.0" {
+								continue
+							}
+						case "uri":
+							if _, ok := p.GetFirstValue().(*TelValue); ok && c.to != "4.0" {
+								// the phone numbers are text in vcard 2.1 and 3.0
+								continue
+							}
+					}
+				case "LABEL", "SORT-AS":
+					if c.to != "4.0" && (p.GetName() == "ADR" || p.GetName() == "N") {
+						// converted to the LABEL / SORT-STRING properties
+						continue
+					}
+			}
+
+			if c.to != "4.0" && isV4OnlyParameter(name) {
+				c.warn(p.GetName(), name, "parameter not defined in vCard %s", c.to)
+				continue
+			}
+		}
+
+		if len(values) > 0 {
+			c.dst.AddPropertyParameter(np, name, values)
+		}
+	}
+
+	if c.from != "4.0" && c.to == "4.0" && hasParameterValue(p, "TYPE", "pref") {
+		c.dst.AddPropertyParameter(np, "PREF", []string{"1"})
+	}
+}
+
+/**
+ * TYPE=pref is replaced by PREF=1 in vcard 4.0; TYPE=agent is removed when RELATED becomes AGENT
+ */
+func (c *converter) convertTypes(p IProperty, values []string) []string {
+	var removed string
+	switch {
+		case c.to == "4.0":
+			removed = "pref"
+		case p.GetName() == "RELATED":
+			removed = "agent"
+		default:
+			return values
+	}
+
+	var result []string
+	for _, v := range values {
+		if !strings.EqualFold(v, removed) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+/**
+ * inline binary values: ENCODING=b;TYPE=JPEG <-> data:image/jpeg;base64,
+ * external uris: VALUE=uri;TYPE=GIF <-> MEDIATYPE=image/gif
+ */
+func (c *converter) convertMedia(p IProperty, np IProperty) {
+	media, ok := p.GetFirstValue().(*MediaValue)
+	if !ok {
+		// not a binary value (ex: vcard 4.0 text KEY)
+		c.convertParameters(p, np)
+		return
+	}
+	value := copyValue(media).(*MediaValue)
+
+	var mediaType string
+	if t := parameterValues(p, "TYPE"); len(t) > 0 && c.from != "4.0" {
+		mediaType = mediaTypeFromType(p.GetName(), t[0])
+	}
+	if mt := parameterValues(p, "MEDIATYPE"); len(mt) > 0 {
+		mediaType = mt[0]
+	}
+	if mediaType == "" && value.IsDataUri {
+		mediaType = value.MediaType
+	}
+
+	switch c.to {
+		case "4.0":
+			if value.IsB64Encoded && !value.IsUrl {
+				value.IsDataUri = true
+				value.MediaType = mediaType
+			} else if mediaType != "" {
+				c.dst.AddPropertyParameter(np, "MEDIATYPE", []string{mediaType})
+			}
+		default:
+			if value.IsDataUri {
+				if !value.IsB64Encoded {
+					c.warn(p.GetName(), "", "only base64 data uris can be converted to vCard %s", c.to)
+					return
+				}
+				value.IsDataUri = false
+			}
+			switch {
+				case value.IsB64Encoded && !value.IsUrl:
+					c.dst.AddPropertyParameter(np, "ENCODING", []string{"b"})
+				case value.IsUrl:
+					c.dst.AddPropertyParameter(np, "VALUE", []string{"uri"})
+			}
+			if mediaType != "" {
+				c.dst.AddPropertyParameter(np, "TYPE", []string{typeFromMediaType(mediaType)})
+			}
+	}
+	np.SetValue([]IData{value})
+
+	// the other parameters (LANGUAGE, PREF, X-...)
+	for _, param := range p.GetParameters() {
+		name := param.GetName()
+		switch name {
+			case "ENCODING", "TYPE", "MEDIATYPE", "VALUE":
+				continue
+		}
+		if c.to != "4.0" && isV4OnlyParameter(name) {
+			c.warn(p.GetName(), name, "parameter not defined in vCard %s", c.to)
+			continue
+		}
+		c.dst.AddPropertyParameter(np, name, append([]string(nil), param.GetValue()...))
+	}
+}
+
+/**
+ * vcard 4.0 -> 3.0: ADR;LABEL= becomes a LABEL property with the same types
+ */
+func (c *converter) addLabel(adr IProperty, label string) {
+	lp := c.dst.CreateProperty("LABEL")
+	lp.AddValue(NewText(label))
+	if types := parameterValues(adr, "TYPE"); len(types) > 0 {
+		c.dst.AddPropertyParameter(lp, "TYPE", types)
+	}
+	c.dst.AddProperty(lp)
+}
+
+/**
+ * vcard 3.0 -> 4.0: each LABEL is attached to the ADR with the same types, or to the first ADR without label
+ */
+func (c *converter) attachLabels() {
+	for _, label := range c.labels {
+		var target IProperty
+		for _, adr := range c.dst.GetProperty("ADR") {
+			if len(parameterValues(adr, "LABEL")) > 0 {
+				continue
+			}
+			if sameTypes(parameterValues(adr, "TYPE"), parameterValues(label, "TYPE")) {
+				target = adr
+				break
+			}
+			if target == nil {
+				target = adr
+			}
+		}
+
+		value := ""
+		if v := label.GetFirstValue(); v != nil {
+			value = v.GetValue()
+		}
+		if target == nil {
+			c.warn("LABEL", "", "no ADR property to attach the label to")
+			continue
+		}
+		c.dst.AddPropertyParameter(target, "LABEL", []string{value})
+	}
+}
+
+/**
+ * vcard 3.0 -> 4.0: SORT-STRING becomes the SORT-AS parameter of N
+ */
+func (c *converter) attachSortString() {
+	if c.sortString == nil || c.sortString.GetFirstValue() == nil {
+		return
+	}
+	n := c.dst.GetProperty("N")
+	if len(n) == 0 {
+		c.warn("SORT-STRING", "", "no N property to attach the sort string to")
+		return
+	}
+	c.dst.AddPropertyParameter(n[0], "SORT-AS", []string{c.sortString.GetFirstValue().GetValue()})
+}
+
+/**
+ * the global phone numbers given as text are written as tel uris in vcard 4.0 (TEL;VALUE=uri:tel:+1...)
+ */
+func telUris(values []IData) {
+	for i, v := range values {
+		if t, ok := v.(*TextValue); ok {
+			if tel, err := ParseTel(t.GetValue()); err == nil && tel.IsGlobal() {
+				values[i] = tel
+			}
+		}
+	}
+}
+
+/**
+ * copy a value so the converted card does not share values with the source
+ */
+func copyValue(d IData) IData {
+	switch v := d.(type) {
+		case *TextValue:
+			c := *v
+			return &c
+		case *NameValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			c.FamilyName = append([]string(nil), v.FamilyName...)
+			c.GivenName = append([]string(nil), v.GivenName...)
+			c.MiddleName = append([]string(nil), v.MiddleName...)
+			c.HonorificPrefixes = append([]string(nil), v.HonorificPrefixes...)
+			c.HonorificSuffixes = append([]string(nil), v.HonorificSuffixes...)
+			return &c
+		case *AddressValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			return &c
+		case *OrganizationValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			c.Departments = append([]string(nil), v.Departments...)
+			return &c
+		case *GeoValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			return &c
+		case *GenderValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			return &c
+		case *MediaValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			return &c
+		case *EmailValue:
+			c := *v
+			return &c
+		case *TelValue:
+			c := *v
+			return &c
+		case *DateValue:
+			c := *v
+			return &c
+		case *TimeValue:
+			c := *v
+			return &c
+		case *DateTimeValue:
+			c := *v
+			return &c
+		case *DateAndOrTimeValue:
+			c := *v
+			return &c
+		case *TimestampValue:
+			c := *v
+			return &c
+		case *StructuredValue:
+			c := *v
+			c.TextValue = copyTextValue(v.TextValue)
+			c.Components = nil
+			for _, component := range v.Components {
+				c.Components = append(c.Components, append([]string(nil), component...))
+			}
+			return &c
+	}
+	return d
+}
+
+func copyTextValue(v *TextValue) *TextValue {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+/**
+ * media type of a vcard 2.1 / 3.0 TYPE parameter value (JPEG -> image/jpeg)
+ */
+func mediaTypeFromType(property string, t string) string {
+	t = strings.ToLower(t)
+	if strings.Contains(t, "/") {
+		return t
+	}
+	switch (property) {
+		case "SOUND":
+			return "audio/" + t
+		case "KEY":
+			switch (t) {
+				case "pgp":
+					return "application/pgp-keys"
+				case "x509":
+					return "application/pkix-cert"
+			}
+			return "application/" + t
+	}
+	return "image/" + t
+}
+
+/**
+ * vcard 2.1 / 3.0 TYPE parameter value of a media type (image/jpeg -> JPEG)
+ */
+func typeFromMediaType(mediaType string) string {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch (mediaType) {
+		case "application/pgp-keys":
+			return "PGP"
+		case "application/pkix-cert":
+			return "X509"
+	}
+	if i := strings.LastIndexByte(mediaType, '/'); i >= 0 {
+		mediaType = mediaType[i+1:]
+	}
+	return strings.ToUpper(mediaType)
+}
+
+/**
+ * vcard 4.0 parameters that have no equivalent in the older versions (see the parameter schemas)
+ */
+func isV4OnlyParameter(name string) bool {
+	_, v4 := GetParameterSchema("4.0", name)
+	_, v3 := GetParameterSchema("3.0", name)
+	return v4 && !v3
+}
+
+/**
+ * get the values of a parameter of a property (nil if the parameter is missing)
+ */
+func parameterValues(p IProperty, name string) []string {
+	if param := p.GetParameter(name); param != nil {
+		return param.GetValue()
+	}
+	return nil
+}
+
+/**
+ * check if a parameter of a property has a value (case insensitive)
+ */
+func hasParameterValue(p IProperty, name string, value string) bool {
+	for _, v := range parameterValues(p, name) {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * return the PREF parameter of a vcard 4.0 property
+ */
+func prefValue(p IProperty) (int, bool) {
+	pref := parameterValues(p, "PREF")
+	if len(pref) == 0 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(pref[0])
+	return v, err == nil
+}
+
+/**
+ * compare two TYPE lists ignoring the case and the pref type
+ */
+func sameTypes(a []string, b []string) bool {
+	set := map[string]bool{}
+	for _, v := range a {
+		if !strings.EqualFold(v, "pref") {
+			set[strings.ToLower(v)] = true
+		}
+	}
+	count := 0
+	for _, v := range b {
+		if strings.EqualFold(v, "pref") {
+			continue
+		}
+		if !set[strings.ToLower(v)] {
+			return false
+		}
+		count++
+	}
+	return count == len(set)
+}
+
+func firstOf(v []string) string {
+	if len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
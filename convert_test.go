@@ -0,0 +1,108 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertKeepsAlternatives(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:4.0\r\n" +
+		"FN;ALTID=1;LANGUAGE=en:Taro Yamada\r\n" +
+		"FN;ALTID=1;LANGUAGE=ja:山田太郎\r\n" +
+		"BDAY;ALTID=2:19850412\r\n" +
+		"BDAY;ALTID=2;VALUE=text:circa 1985\r\n" +
+		"END:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v4, warnings, err := Convert(card, "4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(v4.GetProperty("FN")); n != 2 {
+		t.Errorf("4.0: %d FN, want the 2 representations", n)
+	}
+	if n := len(v4.GetProperty("BDAY")); n != 2 {
+		t.Errorf("4.0: %d BDAY, want the 2 representations", n)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("4.0 warnings: %v", warnings)
+	}
+
+	v3, _, err := Convert(card, "3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(v3.GetProperty("FN")); n != 1 {
+		t.Errorf("3.0: %d FN", n)
+	}
+}
+
+func TestConvertV3ToV4AndBack(t *testing.T) {
+	photo := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+	card, err := Parse("BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:John Doe\r\n" +
+		"N:Doe;John;;;\r\n" +
+		"NAME:John's card\r\n" +
+		"PROFILE:VCARD\r\n" +
+		"MAILER:Mail 1.0\r\n" +
+		"CLASS:PUBLIC\r\n" +
+		"EMAIL;TYPE=internet,pref:john@example.com\r\n" +
+		"ADR;TYPE=work:;;1 Main St;Town;;;\r\n" +
+		"LABEL;TYPE=work:1 Main St\\nTown\r\n" +
+		"AGENT;VALUE=uri:http://example.com/agent.vcf\r\n" +
+		"PHOTO;ENCODING=b;TYPE=PNG:" + photo + "\r\n" +
+		"END:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v4, warnings, err := Convert(card, "4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := v4.Build()
+	for _, want := range []string{
+		"\r\nEMAIL;TYPE=internet;PREF=1:john@example.com\r\n",
+		"\r\nADR;TYPE=work;LABEL=1 Main St^nTown:;;1 Main St;Town;;;\r\n",
+		"\r\nRELATED;VALUE=uri;TYPE=agent:http://example.com/agent.vcf\r\n",
+		"\r\nPHOTO:data:image/png;base64,",
+	} {
+		if !strings.Contains(Unfold(out), want) {
+			t.Errorf("4.0: %q not found in\n%s", want, out)
+		}
+	}
+	for _, name := range []string{"NAME", "PROFILE", "MAILER", "CLASS", "LABEL", "AGENT"} {
+		if len(v4.GetProperty(name)) > 0 {
+			t.Errorf("4.0: %s is kept", name)
+		}
+	}
+	dropped := map[string]bool{}
+	for _, w := range warnings {
+		dropped[w.Property] = true
+	}
+	for _, name := range []string{"NAME", "PROFILE", "MAILER", "CLASS"} {
+		if !dropped[name] {
+			t.Errorf("4.0: no warning for %s: %v", name, warnings)
+		}
+	}
+
+	v3, _, err := Convert(v4, "3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = Unfold(v3.Build())
+	for _, want := range []string{
+		"\r\nEMAIL;TYPE=internet,pref:john@example.com\r\n",
+		"\r\nADR;TYPE=work:;;1 Main St;Town;;;\r\n",
+		"\r\nLABEL;TYPE=work:1 Main St\\nTown\r\n",
+		"\r\nAGENT;VALUE=uri:http://example.com/agent.vcf\r\n",
+		"\r\nPHOTO;ENCODING=b;TYPE=PNG:" + photo + "\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("3.0: %q not found in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,620 @@
+/**
+ * date and time values (RFC 6350 4.3, RFC 2426 5.8.4): DATE, TIME, DATE-TIME, DATE-AND-OR-TIME and TIMESTAMP
+ *
+ * the values keep the components they were created with, so the reduced (1985, 1985-04) and truncated
+ * (--0412, ---12, -2200) forms are written back as they are
+ * vcard 4.0 and xCard use the ISO 8601 basic format (19850412, 102200-0500), vcard 2.1, 3.0 and jCard
+ * the extended format (1985-04-12, 10:22:00-05:00); both formats are read
+ */
+package vcard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * value of a missing component
+ */
+const DateTimeUnset = -1
+
+/**
+ * components of a date and/or time
+ */
+type DateTimeParts struct {
+	// DateTimeUnset if missing (--0412 has no year, 1985 has no month and day)
+	Year int
+	Month int
+	Day int
+
+	// DateTimeUnset if missing (T10 has no minute and second, T-2200 has no hour)
+	Hour int
+	Minute int
+	Second int
+
+	// the time has an utc offset; Offset is in seconds east of UTC, 0 is written as "Z"
+	HasZone bool
+	Offset int
+}
+
+func unsetDateTimeParts() DateTimeParts {
+	return DateTimeParts{
+		Year: DateTimeUnset,
+		Month: DateTimeUnset,
+		Day: DateTimeUnset,
+		Hour: DateTimeUnset,
+		Minute: DateTimeUnset,
+		Second: DateTimeUnset,
+	}
+}
+
+func (p DateTimeParts) hasDate() bool {
+	return p.Year != DateTimeUnset || p.Month != DateTimeUnset || p.Day != DateTimeUnset
+}
+
+func (p DateTimeParts) hasTime() bool {
+	return p.Hour != DateTimeUnset || p.Minute != DateTimeUnset || p.Second != DateTimeUnset
+}
+
+/**
+ * the value as a time.Time; false if the date is not complete
+ * the missing time components are 0, a time without utc offset is returned in UTC and a time without date is on January 1 of year 0
+ */
+func (p DateTimeParts) Time() (time.Time, bool) {
+	year, month, day := p.Year, p.Month, p.Day
+	if !p.hasDate() {
+		if !p.hasTime() {
+			return time.Time{}, false
+		}
+		year, month, day = 0, 1, 1
+	} else if year == DateTimeUnset || month == DateTimeUnset || day == DateTimeUnset {
+		return time.Time{}, false
+	}
+
+	hour, minute, second := p.Hour, p.Minute, p.Second
+	if hour == DateTimeUnset {
+		hour = 0
+	}
+	if minute == DateTimeUnset {
+		minute = 0
+	}
+	if second == DateTimeUnset {
+		second = 0
+	}
+
+	loc := time.UTC
+	if p.HasZone && p.Offset != 0 {
+		loc = time.FixedZone("", p.Offset)
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, 0, loc), true
+}
+
+/**
+ * check the components against the forms of a value type
+ *   date             = year [month day] / year "-" month / "--" month [day] / "--" "-" day
+ *   time             = hour [minute [second]] [zone] / "-" minute [second] [zone] / "-" "-" second [zone]
+ *   date-time        = date-noreduc "T" time-notrunc
+ *   date-and-or-time = date-time / date / "T" time
+ *   timestamp        = date-complete "T" time-complete
+ */
+func (p DateTimeParts) validFor(valueType string) bool {
+	if !p.validRanges() {
+		return false
+	}
+
+	// year + day without month, hour + second without minute
+	date := p.hasDate() && !(p.Year != DateTimeUnset && p.Month == DateTimeUnset && p.Day != DateTimeUnset)
+	dateNoReduc := date && p.Day != DateTimeUnset
+	tm := p.hasTime() && !(p.Hour != DateTimeUnset && p.Minute == DateTimeUnset && p.Second != DateTimeUnset)
+	timeNoTrunc := tm && p.Hour != DateTimeUnset
+
+	switch (valueType) {
+		case "date":
+			return date && !p.hasTime() && !p.HasZone
+		case "time":
+			return tm && !p.hasDate()
+		case "date-time":
+			return dateNoReduc && timeNoTrunc
+		case "date-and-or-time":
+			return dateNoReduc && timeNoTrunc || date && !p.hasTime() && !p.HasZone || tm && !p.hasDate()
+		case "timestamp":
+			return p.Year != DateTimeUnset && p.Month != DateTimeUnset && p.Day != DateTimeUnset &&
+				p.Hour != DateTimeUnset && p.Minute != DateTimeUnset && p.Second != DateTimeUnset
+	}
+	return false
+}
+
+func (p DateTimeParts) validRanges() bool {
+	if p.Year != DateTimeUnset && (p.Year < 0 || p.Year > 9999) {
+		return false
+	}
+	if p.Month != DateTimeUnset && (p.Month < 1 || p.Month > 12) {
+		return false
+	}
+	if p.Day != DateTimeUnset {
+		// without year, February 29 is allowed
+		year, month := p.Year, p.Month
+		if year == DateTimeUnset {
+			year = 2000
+		}
+		if month == DateTimeUnset {
+			month = 1
+		}
+		if p.Day < 1 || p.Day > time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+			return false
+		}
+	}
+	if p.Hour != DateTimeUnset && (p.Hour < 0 || p.Hour > 23) {
+		return false
+	}
+	if p.Minute != DateTimeUnset && (p.Minute < 0 || p.Minute > 59) {
+		return false
+	}
+	// 60 for leap seconds
+	if p.Second != DateTimeUnset && (p.Second < 0 || p.Second > 60) {
+		return false
+	}
+	if p.HasZone && (p.Offset <= -24*3600 || p.Offset >= 24*3600 || p.Offset%60 != 0) {
+		return false
+	}
+	if p.HasZone && !p.hasTime() {
+		return false
+	}
+	return true
+}
+
+/**
+ * write the value in the basic (19850412T102200Z) or extended (1985-04-12T10:22:00Z) format
+ * a time without date is written with the "T" designator, except for the TIME values
+ */
+func (p DateTimeParts) format(valueType string, extended bool) string {
+	var s strings.Builder
+	if p.hasDate() {
+		p.formatDate(&s, extended)
+	}
+	if p.hasTime() {
+		if p.hasDate() || valueType != "time" {
+			s.WriteString("T")
+		}
+		p.formatTime(&s, extended)
+	}
+	return s.String()
+}
+
+func (p DateTimeParts) formatDate(s *strings.Builder, extended bool) {
+	switch {
+		case p.Year != DateTimeUnset:
+			fmt.Fprintf(s, "%04d", p.Year)
+			if p.Month != DateTimeUnset {
+				// year "-" month is the only reduced form with a separator in the basic format
+				if extended || p.Day == DateTimeUnset {
+					s.WriteString("-")
+				}
+				fmt.Fprintf(s, "%02d", p.Month)
+			}
+		case p.Month != DateTimeUnset:
+			fmt.Fprintf(s, "--%02d", p.Month)
+		default:
+			s.WriteString("--")
+	}
+	if p.Day != DateTimeUnset {
+		if extended || p.Month == DateTimeUnset {
+			s.WriteString("-")
+		}
+		fmt.Fprintf(s, "%02d", p.Day)
+	}
+}
+
+func (p DateTimeParts) formatTime(s *strings.Builder, extended bool) {
+	separator := ""
+	if extended {
+		separator = ":"
+	}
+
+	switch {
+		case p.Hour != DateTimeUnset:
+			fmt.Fprintf(s, "%02d", p.Hour)
+			if p.Minute != DateTimeUnset {
+				s.WriteString(separator)
+			}
+		case p.Minute != DateTimeUnset:
+			s.WriteString("-")
+		default:
+			s.WriteString("--")
+	}
+	if p.Minute != DateTimeUnset {
+		fmt.Fprintf(s, "%02d", p.Minute)
+		if p.Second != DateTimeUnset {
+			s.WriteString(separator)
+		}
+	}
+	if p.Second != DateTimeUnset {
+		fmt.Fprintf(s, "%02d", p.Second)
+	}
+
+	if !p.HasZone {
+		return
+	}
+	if p.Offset == 0 {
+		s.WriteString("Z")
+		return
+	}
+	offset := p.Offset
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	fmt.Fprintf(s, "%s%02d%s%02d", sign, offset/3600, separator, offset%3600/60)
+}
+
+/**
+ * parse a date and/or time in the basic or extended format; with timeOnly the text is a time without the "T" designator
+ */
+func parseDateTimeParts(s string, timeOnly bool) (DateTimeParts, error) {
+	p := unsetDateTimeParts()
+	date, tm := s, ""
+	if timeOnly {
+		date, tm = "", strings.TrimPrefix(s, "T")
+	} else if i := strings.IndexByte(s, 'T'); i >= 0 {
+		date, tm = s[:i], s[i+1:]
+		if tm == "" {
+			return p, fmt.Errorf("missing time in %q", s)
+		}
+	}
+
+	if date != "" {
+		if err := p.parseDate(date); err != nil {
+			return p, err
+		}
+	}
+	if tm != "" {
+		if err := p.parseTime(tm); err != nil {
+			return p, err
+		}
+	}
+	if !p.hasDate() && !p.hasTime() {
+		return p, fmt.Errorf("empty date-time %q", s)
+	}
+	return p, nil
+}
+
+func (p *DateTimeParts) parseDate(s string) error {
+	var (
+		digits string
+		fields []*int
+	)
+	switch {
+		case strings.HasPrefix(s, "---"):
+			digits = s[3:]
+			fields = []*int{&p.Day}
+		case strings.HasPrefix(s, "--"):
+			digits = strings.Replace(s[2:], "-", "", 1)
+			fields = []*int{&p.Month, &p.Day}
+		default:
+			digits = strings.Replace(s, "-", "", 2)
+			if len(digits) < 4 {
+				return fmt.Errorf("invalid date %q", s)
+			}
+			year, err := parseDigits(digits[:4])
+			if err != nil {
+				return fmt.Errorf("invalid date %q", s)
+			}
+			p.Year = year
+			digits = digits[4:]
+			fields = []*int{&p.Month, &p.Day}
+	}
+	if p.Year == DateTimeUnset && digits == "" {
+		return fmt.Errorf("invalid date %q", s)
+	}
+	if err := setDigitPairs(digits, fields); err != nil {
+		return fmt.Errorf("invalid date %q", s)
+	}
+	return nil
+}
+
+func (p *DateTimeParts) parseTime(s string) error {
+	original := s
+
+	// zone: Z, or a sign after the first digits (the leading "-" are truncated components)
+	if strings.HasSuffix(s, "Z") {
+		p.HasZone = true
+		s = s[:len(s)-1]
+	} else if i := strings.LastIndexAny(s, "+-"); i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		zone := strings.Replace(s[i+1:], ":", "", 1)
+		s = s[:i]
+		if len(zone) != 2 && len(zone) != 4 {
+			return fmt.Errorf("invalid utc offset in %q", original)
+		}
+		hours, err := parseDigits(zone[:2])
+		minutes := 0
+		if err == nil && len(zone) == 4 {
+			minutes, err = parseDigits(zone[2:])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid utc offset in %q", original)
+		}
+		p.HasZone = true
+		p.Offset = hours*3600 + minutes*60
+		if original[i] == '-' {
+			p.Offset = -p.Offset
+		}
+	}
+
+	// fractions of seconds (ISO 8601, vcard 3.0) are dropped
+	if i := strings.IndexAny(s, ".,"); i >= 0 {
+		if _, err := parseDigits(s[i+1:]); err != nil {
+			return fmt.Errorf("invalid time %q", original)
+		}
+		s = s[:i]
+	}
+	s = strings.Replace(s, ":", "", 2)
+
+	fields := []*int{&p.Hour, &p.Minute, &p.Second}
+	switch {
+		case strings.HasPrefix(s, "--"):
+			s, fields = s[2:], fields[2:]
+		case strings.HasPrefix(s, "-"):
+			s, fields = s[1:], fields[1:]
+	}
+	if s == "" || setDigitPairs(s, fields) != nil {
+		return fmt.Errorf("invalid time %q", original)
+	}
+	return nil
+}
+
+/**
+ * set the fields from pairs of digits; the last fields may be missing
+ */
+func setDigitPairs(digits string, fields []*int) error {
+	if len(digits)%2 != 0 || len(digits)/2 > len(fields) {
+		return fmt.Errorf("invalid digits %q", digits)
+	}
+	for i := 0; i < len(digits); i += 2 {
+		n, err := parseDigits(digits[i : i+2])
+		if err != nil {
+			return err
+		}
+		*fields[i/2] = n
+	}
+	return nil
+}
+
+func parseDigits(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("missing digits")
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid digit %q", c)
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+/**
+ * base of the date and time values; valueType is the form of the value (date, time, date-time...)
+ */
+type dateTimeValue struct {
+	DateTimeParts
+	valueType string
+
+	// text given to SetValue that is not a date-time; the value is then invalid
+	raw string
+}
+
+/**
+ * date and time values (see ValidateData)
+ */
+type dateTimeData interface {
+	IData
+	validFor(valueType string) bool
+	extendedString() string
+}
+
+func newDateTimeValue(valueType string) dateTimeValue {
+	return dateTimeValue{
+		DateTimeParts: unsetDateTimeParts(),
+		valueType: valueType,
+	}
+}
+
+func (v *dateTimeValue) GetType() string {
+	return strings.ToUpper(v.valueType)
+}
+
+func (v *dateTimeValue) Validate() bool {
+	return v.raw == "" && v.DateTimeParts.validFor(v.valueType)
+}
+
+/**
+ * parse a date-time in the basic or extended format; a text that can not be parsed is kept and the value is invalid
+ */
+func (v *dateTimeValue) SetValue(s string) {
+	p, err := parseDateTimeParts(s, v.valueType == "time")
+	if err != nil {
+		v.DateTimeParts, v.raw = unsetDateTimeParts(), s
+		return
+	}
+	v.DateTimeParts, v.raw = p, ""
+}
+
+/**
+ * the value in the basic format (vcard 4.0)
+ */
+func (v *dateTimeValue) GetValue() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	return v.format(v.valueType, false)
+}
+
+func (v *dateTimeValue) IsEmpty() bool {
+	return v.raw == "" && !v.hasDate() && !v.hasTime()
+}
+
+func (v *dateTimeValue) GetString() string {
+	return v.GetValue()
+}
+
+/**
+ * basic format for vcard 4.0, extended format for vcard 2.1 and 3.0
+ */
+func (v *dateTimeValue) GetVersionedString(version string) string {
+	if version == "4.0" {
+		return v.GetValue()
+	}
+	return v.extendedString()
+}
+
+func (v *dateTimeValue) extendedString() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	return v.format(v.valueType, true)
+}
+
+/**
+ * components of a time.Time
+ */
+func (v *dateTimeValue) setTime(t time.Time, date bool, tm bool) {
+	v.DateTimeParts, v.raw = unsetDateTimeParts(), ""
+	if date {
+		v.Year, v.Month, v.Day = t.Year(), int(t.Month()), t.Day()
+	}
+	if tm {
+		v.Hour, v.Minute, v.Second = t.Hour(), t.Minute(), t.Second()
+		_, v.Offset = t.Zone()
+		v.HasZone = true
+	}
+}
+
+type DateValue struct {
+	dateTimeValue
+}
+
+type TimeValue struct {
+	dateTimeValue
+}
+
+type DateTimeValue struct {
+	dateTimeValue
+}
+
+type DateAndOrTimeValue struct {
+	dateTimeValue
+}
+
+type TimestampValue struct {
+	dateTimeValue
+}
+
+/**
+ * date of t (year, month and day)
+ */
+func NewDate(t time.Time) *DateValue {
+	v := &DateValue{newDateTimeValue("date")}
+	v.setTime(t, true, false)
+	return v
+}
+
+/**
+ * time of t, with its utc offset
+ */
+func NewTime(t time.Time) *TimeValue {
+	v := &TimeValue{newDateTimeValue("time")}
+	v.setTime(t, false, true)
+	return v
+}
+
+func NewDateTime(t time.Time) *DateTimeValue {
+	v := &DateTimeValue{newDateTimeValue("date-time")}
+	v.setTime(t, true, true)
+	return v
+}
+
+/**
+ * date and time of t; use NewDateAndOrTimeParts for a date or a time only
+ */
+func NewDateAndOrTime(t time.Time) *DateAndOrTimeValue {
+	v := &DateAndOrTimeValue{newDateTimeValue("date-and-or-time")}
+	v.setTime(t, true, true)
+	return v
+}
+
+/**
+ * value with the given components (ex: a birthday without year)
+ */
+func NewDateAndOrTimeParts(p DateTimeParts) *DateAndOrTimeValue {
+	v := &DateAndOrTimeValue{newDateTimeValue("date-and-or-time")}
+	v.DateTimeParts = p
+	return v
+}
+
+func NewTimestamp(t time.Time) *TimestampValue {
+	v := &TimestampValue{newDateTimeValue("timestamp")}
+	v.setTime(t, true, true)
+	return v
+}
+
+func ParseDate(s string) (*DateValue, error) {
+	v := &DateValue{newDateTimeValue("date")}
+	return v, parseDateTimeValue(&v.dateTimeValue, s)
+}
+
+func ParseTime(s string) (*TimeValue, error) {
+	v := &TimeValue{newDateTimeValue("time")}
+	return v, parseDateTimeValue(&v.dateTimeValue, s)
+}
+
+func ParseDateTime(s string) (*DateTimeValue, error) {
+	v := &DateTimeValue{newDateTimeValue("date-time")}
+	return v, parseDateTimeValue(&v.dateTimeValue, s)
+}
+
+func ParseDateAndOrTime(s string) (*DateAndOrTimeValue, error) {
+	v := &DateAndOrTimeValue{newDateTimeValue("date-and-or-time")}
+	return v, parseDateTimeValue(&v.dateTimeValue, s)
+}
+
+func ParseTimestamp(s string) (*TimestampValue, error) {
+	v := &TimestampValue{newDateTimeValue("timestamp")}
+	return v, parseDateTimeValue(&v.dateTimeValue, s)
+}
+
+func parseDateTimeValue(v *dateTimeValue, s string) error {
+	v.SetValue(s)
+	if !v.Validate() {
+		return fmt.Errorf("vcard: invalid %s %q", v.valueType, s)
+	}
+	return nil
+}
+
+/**
+ * typed value of a date or time value type; nil if the text is not valid for the type
+ */
+func newDateTimeData(valueType string, s string) IData {
+	var (
+		d IData
+		err error
+	)
+	switch (valueType) {
+		case "date":
+			d, err = ParseDate(s)
+		case "time":
+			d, err = ParseTime(s)
+		case "date-time":
+			d, err = ParseDateTime(s)
+		case "date-and-or-time":
+			d, err = ParseDateAndOrTime(s)
+		case "timestamp":
+			d, err = ParseTimestamp(s)
+		default:
+			return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return d
+}
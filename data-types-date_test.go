@@ -0,0 +1,113 @@
+package vcard
+
+import (
+	"testing"
+)
+
+type dateTimeParser func(s string) (IData, error)
+
+var dateTimeParsers = map[string]dateTimeParser{
+	"date": func(s string) (IData, error) { return ParseDate(s) },
+	"time": func(s string) (IData, error) { return ParseTime(s) },
+	"date-time": func(s string) (IData, error) { return ParseDateTime(s) },
+	"date-and-or-time": func(s string) (IData, error) { return ParseDateAndOrTime(s) },
+	"timestamp": func(s string) (IData, error) { return ParseTimestamp(s) },
+}
+
+/**
+ * every form of RFC 6350 4.3 in the basic format (vcard 4.0) and the extended format (vcard 3.0)
+ */
+var dateTimeTests = []struct {
+	valueType string
+	basic string
+	extended string
+}{
+	// dates, reduced and truncated
+	{"date", "19850412", "1985-04-12"},
+	{"date", "1985-04", "1985-04"},
+	{"date", "1985", "1985"},
+	{"date", "--0412", "--04-12"},
+	{"date", "--04", "--04"},
+	{"date", "---12", "---12"},
+
+	// times, reduced and truncated, with a zone
+	{"time", "102200", "10:22:00"},
+	{"time", "1022", "10:22"},
+	{"time", "10", "10"},
+	{"time", "-2200", "-22:00"},
+	{"time", "--00", "--00"},
+	{"time", "102200Z", "10:22:00Z"},
+	{"time", "102200-0800", "10:22:00-08:00"},
+	{"time", "1022+0530", "10:22+05:30"},
+
+	// date-times
+	{"date-time", "19961022T140000", "1996-10-22T14:00:00"},
+	{"date-time", "--1022T1400", "--10-22T14:00"},
+	{"date-time", "---22T14", "---22T14"},
+	{"date-time", "19961022T140000Z", "1996-10-22T14:00:00Z"},
+	{"date-time", "19961022T140000-0500", "1996-10-22T14:00:00-05:00"},
+
+	// date-and-or-time: a date, a date-time or a time with the "T" designator
+	{"date-and-or-time", "19850412", "1985-04-12"},
+	{"date-and-or-time", "--0412", "--04-12"},
+	{"date-and-or-time", "19850412T102200Z", "1985-04-12T10:22:00Z"},
+	{"date-and-or-time", "T102200", "T10:22:00"},
+	{"date-and-or-time", "T1022", "T10:22"},
+	{"date-and-or-time", "T10", "T10"},
+	{"date-and-or-time", "T-2200", "T-22:00"},
+	{"date-and-or-time", "T--00", "T--00"},
+	{"date-and-or-time", "T102200-0500", "T10:22:00-05:00"},
+
+	// timestamps
+	{"timestamp", "19961022T140000", "1996-10-22T14:00:00"},
+	{"timestamp", "19961022T140000Z", "1996-10-22T14:00:00Z"},
+	{"timestamp", "19961022T140000+0530", "1996-10-22T14:00:00+05:30"},
+}
+
+func TestDateTimeRoundTrip(t *testing.T) {
+	for _, tt := range dateTimeTests {
+		parse := dateTimeParsers[tt.valueType]
+		for _, s := range []string{tt.basic, tt.extended} {
+			d, err := parse(s)
+			if err != nil {
+				t.Errorf("%s %q: %v", tt.valueType, s, err)
+				continue
+			}
+			v := d.(IVersionedData)
+			if got := v.GetVersionedString("4.0"); got != tt.basic {
+				t.Errorf("%s %q: basic format %q, want %q", tt.valueType, s, got, tt.basic)
+			}
+			if got := v.GetVersionedString("3.0"); got != tt.extended {
+				t.Errorf("%s %q: extended format %q, want %q", tt.valueType, s, got, tt.extended)
+			}
+		}
+	}
+}
+
+func TestDateTimeInvalid(t *testing.T) {
+	tests := []struct {
+		valueType string
+		value string
+	}{
+		{"date", "1985-13-01"},
+		{"date", "19850230"},
+		{"date", "85"},
+		{"date", "1985041"},
+		{"date", "19850412T1022"},
+		{"time", "25"},
+		{"time", "1060"},
+		{"time", "1022+5"},
+		{"time", "10:22:00+1"},
+		{"date-time", "19850412"},
+		{"date-time", "19850412T"},
+		{"date-and-or-time", "T"},
+		{"date-and-or-time", "abc"},
+		{"timestamp", "1985"},
+		{"timestamp", "--0412T102200"},
+	}
+	for _, tt := range tests {
+		if d, err := dateTimeParsers[tt.valueType](tt.value); err == nil {
+			t.Errorf("%s %q is accepted: %q", tt.valueType, tt.value, d.GetValue())
+		}
+	}
+}
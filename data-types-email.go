@@ -0,0 +1,153 @@
+/**
+ * email addresses (EMAIL, RFC 6350 6.4.2)
+ *
+ * the value is the bare address (local@domain), without display name or angle brackets, in all versions;
+ * the local part may be UTF-8 (RFC 6531) and the domain an internationalized domain, kept in the form it was given
+ */
+package vcard
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+/**
+ * limits of RFC 5321 4.5.3.1
+ */
+const (
+	maxEmailLocalLength = 64
+	maxEmailLength = 254
+)
+
+type EmailValue struct {
+	// part before the last "@"
+	Local string
+
+	// part after the last "@", in the form it was given (Unicode or ASCII)
+	Domain string
+
+	// text given to SetValue that is not an address; the value is then invalid
+	raw string
+}
+
+func NewEmail(local string, domain string) *EmailValue {
+	return &EmailValue{
+		Local: local,
+		Domain: domain,
+	}
+}
+
+/**
+ * parse an address; the display name and the angle brackets are dropped ("Jane <jane@example.com>"),
+ * as the "mailto:" prefix
+ */
+func ParseEmail(s string) (*EmailValue, error) {
+	v := &EmailValue{}
+	v.SetValue(s)
+	if !v.Validate() {
+		return v, fmt.Errorf("vcard: invalid email address %q", s)
+	}
+	return v, nil
+}
+
+func (v *EmailValue) GetType() string {
+	return "EMAIL"
+}
+
+/**
+ * the ASCII form of the address is checked with IsEmail, the UTF-8 chars of the local part being accepted as letters
+ */
+func (v *EmailValue) Validate() bool {
+	if v.raw != "" || v.Local == "" || v.Domain == "" {
+		return false
+	}
+	if len(v.Local) > maxEmailLocalLength || strings.HasPrefix(v.Local, ".") || strings.HasSuffix(v.Local, ".") || strings.Contains(v.Local, "..") {
+		return false
+	}
+	domain, err := v.ASCIIDomain()
+	if err != nil {
+		return false
+	}
+	local := strings.Map(func(c rune) rune {
+		if c >= 0x80 {
+			return 'a'
+		}
+		return c
+	}, v.Local)
+	address := local + "@" + domain
+	return len(v.Local) + 1 + len(domain) <= maxEmailLength && IsEmail(address)
+}
+
+/**
+ * a text that is not an address is kept and the value is invalid
+ */
+func (v *EmailValue) SetValue(s string) {
+	s = strings.TrimSpace(s)
+	address := strings.TrimPrefix(s, "mailto:")
+	if strings.ContainsAny(address, "<\"") {
+		// display name, quoted local part
+		if a, err := mail.ParseAddress(address); err == nil {
+			address = a.Address
+		}
+	}
+
+	i := strings.LastIndexByte(address, '@')
+	if i <= 0 || i == len(address)-1 {
+		v.Local, v.Domain, v.raw = "", "", s
+		return
+	}
+	v.Local, v.Domain, v.raw = address[:i], address[i+1:], ""
+}
+
+func (v *EmailValue) GetValue() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	return v.Local + "@" + v.Domain
+}
+
+func (v *EmailValue) IsEmpty() bool {
+	return v.raw == "" && v.Local == "" && v.Domain == ""
+}
+
+func (v *EmailValue) GetString() string {
+	return EscapeText(v.GetValue())
+}
+
+/**
+ * the domain with its internationalized labels converted to punycode (xn--bcher-kva.example)
+ */
+func (v *EmailValue) ASCIIDomain() (string, error) {
+	return DomainToASCII(v.Domain)
+}
+
+/**
+ * the domain with its punycode labels converted to Unicode (bücher.example)
+ */
+func (v *EmailValue) UnicodeDomain() (string, error) {
+	return DomainToUnicode(v.Domain)
+}
+
+/**
+ * the address with the ASCII domain; the local part is kept (an UTF-8 local part has no ASCII form)
+ */
+func (v *EmailValue) ASCII() string {
+	domain, err := v.ASCIIDomain()
+	if err != nil {
+		return v.GetValue()
+	}
+	return v.Local + "@" + domain
+}
+
+/**
+ * key to compare addresses: lower case local part and lower case ASCII domain; empty for an invalid address
+ * the local part is case sensitive for RFC 5321, but no mail server makes the difference
+ */
+func (v *EmailValue) ComparisonKey() string {
+	if !v.Validate() {
+		return ""
+	}
+	domain, _ := v.ASCIIDomain()
+	return strings.ToLower(v.Local) + "@" + domain
+}
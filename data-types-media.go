@@ -0,0 +1,263 @@
+/**
+ * binary media values: PHOTO, LOGO, SOUND and KEY
+ *
+ * a media value is inline data (kept base64 encoded), an external uri or a data uri; it is written in the form of
+ * the card version: vcard 2.1 and 3.0 write the inline data with ENCODING=BASE64 / ENCODING=b and TYPE=JPEG,
+ * vcard 4.0 as a data uri (data:image/jpeg;base64,...) and the external uris with MEDIATYPE (see impliedParameters)
+ */
+package vcard
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type MediaValue struct {
+	*TextValue
+	IsUrl  bool // if the value is an url;
+	IsB64Encoded bool // if the value is a base64 encoded string
+	IsDataUri bool // if the value was a data uri (data:image/jpeg;base64,...); the value keeps only the data
+	MediaType string // mime type of the value
+}
+
+/**
+ * former name of MediaValue
+ */
+type PhotoValue = MediaValue
+
+var errNoInlineData = errors.New("vcard: the media value is not inline data")
+
+func (v *MediaValue) IsEmpty() bool {
+	return v.value == ""
+}
+
+/**
+ * "PHOTO" for all the media properties (see ValidateData)
+ */
+func (v *MediaValue) GetType() string {
+	return "PHOTO"
+}
+
+/**
+ * an uri must be absolute, the inline data must be base64
+ */
+func (v *MediaValue) Validate() bool {
+	switch {
+		case v.IsUrl:
+			return hasUriScheme(v.value)
+		case v.IsB64Encoded:
+			_, err := decodeBase64(v.value)
+			return err == nil
+	}
+	return true
+}
+
+/**
+ * uris are not escaped; a data uri is rebuilt from the media type and the data
+ */
+func (v *MediaValue) GetString() string {
+	if v.IsDataUri {
+		return v.dataUri()
+	}
+	if v.IsUrl {
+		return v.value
+	}
+	return v.TextValue.GetString()
+}
+
+/**
+ * the inline data is a data uri in vcard 4.0 and the bare base64 data in vcard 2.1 and 3.0
+ */
+func (v *MediaValue) GetVersionedString(version string) string {
+	if v.IsUrl || !v.IsB64Encoded {
+		return v.GetString()
+	}
+	if version == "4.0" {
+		return v.dataUri()
+	}
+	return v.value
+}
+
+func (v *MediaValue) dataUri() string {
+	var s strings.Builder
+	s.WriteString("data:")
+	s.WriteString(v.MediaType)
+	if v.IsB64Encoded {
+		s.WriteString(";base64")
+	}
+	s.WriteString(",")
+	s.WriteString(v.value)
+	return s.String()
+}
+
+/**
+ * the decoded inline data; an error for the external uris
+ */
+func (v *MediaValue) Bytes() ([]byte, error) {
+	switch {
+		case v.IsUrl:
+			return nil, errNoInlineData
+		case v.IsB64Encoded:
+			return decodeBase64(v.value)
+		case v.IsDataUri:
+			s, err := url.PathUnescape(v.value)
+			return []byte(s), err
+	}
+	return nil, errNoInlineData
+}
+
+/**
+ * replace the value by inline data; the media type is sniffed from the data if empty
+ */
+func (v *MediaValue) SetBytes(data []byte, mediaType string) {
+	if mediaType == "" {
+		mediaType = SniffMediaType(data)
+	}
+	v.IsUrl, v.IsB64Encoded, v.IsDataUri = false, true, false
+	v.MediaType = mediaType
+	v.SetValue(base64.StdEncoding.EncodeToString(data))
+}
+
+/**
+ * detect if a value is a data uri to decompose the uri, an uri, or base64 data; the media type of the data
+ * without type is sniffed from the decoded content
+ */
+func (v *MediaValue) AutodetectValue(s string) {
+	v.IsUrl, v.IsB64Encoded, v.IsDataUri = false, false, false
+	v.MediaType = ""
+
+	switch {
+		case strings.HasPrefix(strings.ToLower(s), "data:"):
+			v.setDataUri(s)
+		case hasUriScheme(s):
+			v.IsUrl = true
+			v.SetValue(s)
+		case IsBase64Encoded(s):
+			v.IsB64Encoded = true
+			v.SetValue(s)
+			if data, err := decodeBase64(s); err == nil {
+				v.MediaType = SniffMediaType(data)
+			}
+		default:
+			v.SetValue(s)
+			v.MediaType = SniffMediaType([]byte(s))
+	}
+}
+
+/**
+ *   dataurl    := "data:" [ mediatype ] [ ";base64" ] "," data
+ */
+func (v *MediaValue) setDataUri(s string) {
+	v.IsDataUri = true
+	header, data := s[len("data:"):], ""
+	if i := strings.IndexByte(header, ','); i >= 0 {
+		header, data = header[:i], header[i+1:]
+	}
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		v.IsB64Encoded = true
+		header = header[:len(header)-len(";base64")]
+	}
+	v.MediaType = header
+	v.SetValue(data)
+
+	if v.MediaType == "" {
+		if decoded, err := v.Bytes(); err == nil {
+			v.MediaType = SniffMediaType(decoded)
+		}
+	}
+}
+
+/**
+ * media type of some content: the types of http.DetectContentType (images, audio, video...) and the
+ * PGP armored keys; without the parameters (text/plain; charset=utf-8 -> text/plain)
+ */
+func SniffMediaType(data []byte) string {
+	head := data
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(head)), "-----BEGIN PGP") {
+		return "application/pgp-keys"
+	}
+	mediaType := http.DetectContentType(data)
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	return mediaType
+}
+
+/**
+ * base64 data, padded or not; the white spaces (line breaks of the folded values) are ignored
+ */
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(c rune) rune {
+		switch (c) {
+			case ' ', '\t', '\r', '\n':
+				return -1
+		}
+		return c
+	}, s)
+	if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+/**
+ * absolute uri (http://..., cid:..., urn:...); base64 data as /9j/4AAQ is not an uri
+ */
+func hasUriScheme(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && !strings.ContainsAny(s, " \t\r\n")
+}
+
+/**
+ * value of any media property from its text (data uri, uri or base64 data)
+ */
+func NewPhoto(s string) *PhotoValue {
+	p := &MediaValue {
+		TextValue: &TextValue{},
+		IsUrl: false,
+		IsB64Encoded: false,
+		MediaType: "", // mime type of the value
+	}
+	if len(s)>0 {
+		p.AutodetectValue(s)
+	}
+	return p
+}
+
+/**
+ * inline data; the media type is sniffed from the data if empty
+ */
+func NewMediaFromBytes(data []byte, mediaType string) *MediaValue {
+	v := NewPhoto("")
+	v.SetBytes(data, mediaType)
+	return v
+}
+
+/**
+ * inline data read from r; the media type is sniffed from the data if empty
+ */
+func NewMediaFromReader(r io.Reader, mediaType string) (*MediaValue, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewMediaFromBytes(data, mediaType), nil
+}
+
+/**
+ * external uri; the media type may be empty
+ */
+func NewMediaUri(uri string, mediaType string) *MediaValue {
+	v := NewPhoto("")
+	v.IsUrl = true
+	v.MediaType = mediaType
+	v.SetValue(uri)
+	return v
+}
@@ -0,0 +1,104 @@
+package vcard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var testJPEG = []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+func TestMediaFromBytes(t *testing.T) {
+	v := NewMediaFromBytes(testJPEG, "")
+	if v.MediaType != "image/jpeg" || !v.IsB64Encoded || v.IsUrl {
+		t.Errorf("sniffed media: %+v", v)
+	}
+	if data, err := v.Bytes(); err != nil || !bytes.Equal(data, testJPEG) {
+		t.Errorf("Bytes() = %v, %v", data, err)
+	}
+
+	r, err := NewMediaFromReader(bytes.NewReader(testJPEG), "image/x-test")
+	if err != nil || r.MediaType != "image/x-test" || r.GetValue() != v.GetValue() {
+		t.Errorf("NewMediaFromReader: %+v, %v", r, err)
+	}
+
+	if _, err := NewMediaUri("http://example.com/a.jpg", "image/jpeg").Bytes(); err != errNoInlineData {
+		t.Errorf("uri Bytes() error %v", err)
+	}
+}
+
+func TestMediaAutodetect(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString(testJPEG)
+	tests := []struct {
+		in string
+		isUrl, isB64, isDataUri bool
+		mediaType string
+	}{
+		{"http://example.com/a.jpg", true, false, false, ""},
+		{data, false, true, false, "image/jpeg"},
+		{"/9j/4AAQSkZJRgABAQAAAQ==", false, true, false, "image/jpeg"},
+		{"data:image/png;base64," + data, false, true, true, "image/png"},
+		{"data:;base64," + data, false, true, true, "image/jpeg"},
+		{"data:,hello%20world", false, false, true, "text/plain"},
+	}
+	for _, tt := range tests {
+		v := NewPhoto(tt.in)
+		if v.IsUrl != tt.isUrl || v.IsB64Encoded != tt.isB64 || v.IsDataUri != tt.isDataUri || v.MediaType != tt.mediaType {
+			t.Errorf("%q: %+v", tt.in, v)
+		}
+		if !v.Validate() {
+			t.Errorf("%q is not valid", tt.in)
+		}
+	}
+
+	if b, _ := NewPhoto("data:,hello%20world").Bytes(); string(b) != "hello world" {
+		t.Errorf("data uri bytes %q", b)
+	}
+	if v := NewPhoto("data:image/png;base64," + data); v.GetString() != "data:image/png;base64,"+data {
+		t.Errorf("data uri is not rebuilt: %q", v.GetString())
+	}
+}
+
+func TestMediaVersionedOutput(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString(testJPEG)
+	tests := map[string]string{
+		"2.1": "PHOTO;ENCODING=BASE64;JPEG:",
+		"3.0": "PHOTO;ENCODING=b;TYPE=JPEG:" + data,
+		"4.0": "PHOTO:data:image/jpeg;base64," + data,
+	}
+	for version, want := range tests {
+		card, _ := NewVCard(version)
+		photo := card.CreateProperty("PHOTO")
+		photo.AddValue(NewMediaFromBytes(testJPEG, "image/jpeg"))
+		card.AddProperty(photo)
+		out := Unfold(card.Build())
+		if !strings.Contains(out, want) || (version == "2.1" && !strings.Contains(out, data)) {
+			t.Errorf("%s: %q", version, out)
+		}
+
+		back, err := Parse(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, ok := back.GetProperty("PHOTO")[0].GetFirstValue().(*MediaValue)
+		if b, _ := m.Bytes(); !ok || !bytes.Equal(b, testJPEG) {
+			t.Errorf("%s: read back %+v", version, back.GetProperty("PHOTO")[0].GetFirstValue())
+		}
+	}
+
+	uris := map[string]string{
+		"2.1": "PHOTO;VALUE=URL:http://example.com/a.jpg",
+		"3.0": "PHOTO;VALUE=uri:http://example.com/a.jpg",
+		"4.0": "PHOTO;MEDIATYPE=image/jpeg:http://example.com/a.jpg",
+	}
+	for version, want := range uris {
+		card, _ := NewVCard(version)
+		photo := card.CreateProperty("PHOTO")
+		photo.AddValue(NewMediaUri("http://example.com/a.jpg", "image/jpeg"))
+		card.AddProperty(photo)
+		if out := Unfold(card.Build()); !strings.Contains(out, want) {
+			t.Errorf("%s: %q", version, out)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+/**
+ * telephone numbers (RFC 6350 6.4.1, RFC 3966)
+ *
+ * the numbers are normalized: a global number is kept in the E.164 form (+15551234567), separators and
+ * vanity letters are not kept; vcard 4.0 writes the global numbers as tel uris (tel:+15551234567;ext=89) with
+ * VALUE=uri, vcard 2.1 and 3.0 as text (+15551234567 ext. 89)
+ */
+package vcard
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * TYPE values of the TEL property (RFC 6350 6.4.1)
+ */
+const (
+	TelTypeVoice = "voice"
+	TelTypeFax = "fax"
+	TelTypeCell = "cell"
+	TelTypePager = "pager"
+	TelTypeText = "text"
+	TelTypeTextPhone = "textphone"
+	TelTypeVideo = "video"
+)
+
+var TelTypes = []string{TelTypeVoice, TelTypeFax, TelTypeCell, TelTypePager, TelTypeText, TelTypeTextPhone, TelTypeVideo}
+
+/**
+ * an E.164 number has at most 15 digits, the country calling code included
+ */
+const maxE164Digits = 15
+
+type TelValue struct {
+	// digits of the number, with a "+" prefix for the global numbers (E.164)
+	Number string
+
+	// extension digits; empty if none
+	Extension string
+
+	// other tel uri parameters (";isub=123"), written back as they are
+	params string
+
+	// text given to SetValue that is not a phone number; the value is then invalid
+	raw string
+}
+
+/**
+ * number and extension given as digits; the number may have the "+" prefix (see ParseTel for the human input)
+ */
+func NewTel(number string, extension string) *TelValue {
+	return &TelValue{
+		Number: number,
+		Extension: extension,
+	}
+}
+
+/**
+ * parse a tel uri (tel:+1-555-123-4567;ext=89) or a number as typed by a person:
+ *   +1 (555) 123-4567 ext. 89, 0044 20 7946 0958 x12, 555.123.4567#89
+ * the "00" prefix is the international prefix; a number without prefix is a local number (see ParseTelWithCallingCode)
+ */
+func ParseTel(s string) (*TelValue, error) {
+	return ParseTelWithCallingCode(s, "")
+}
+
+/**
+ * parse a number (see ParseTel); a local number is made global with the country calling code (ex: "1", "44"),
+ * after removing its trunk prefix (the leading "0", or the leading "1" of the 11 digits NANP numbers)
+ */
+func ParseTelWithCallingCode(s string, callingCode string) (*TelValue, error) {
+	v := &TelValue{}
+	v.SetValue(s)
+	if !v.Validate() {
+		return v, fmt.Errorf("vcard: invalid phone number %q", s)
+	}
+	if !v.IsGlobal() && callingCode != "" {
+		number := v.Number
+		if callingCode == "1" && len(number) == 11 && number[0] == '1' {
+			number = number[1:]
+		} else if strings.HasPrefix(number, "0") {
+			number = number[1:]
+		}
+		v.Number = "+" + strings.TrimPrefix(callingCode, "+") + number
+		if !v.Validate() {
+			return v, fmt.Errorf("vcard: invalid phone number %q", s)
+		}
+	}
+	return v, nil
+}
+
+func (v *TelValue) GetType() string {
+	return "TEL"
+}
+
+/**
+ * a global number has a country calling code (not starting with 0) and at most 15 digits
+ */
+func (v *TelValue) Validate() bool {
+	if v.raw != "" || v.Number == "" || !isDigits(v.Extension) {
+		return false
+	}
+	if v.IsGlobal() {
+		digits := v.Number[1:]
+		return digits != "" && digits[0] != '0' && len(digits) <= maxE164Digits && isDigits(digits)
+	}
+	return isDigits(v.Number)
+}
+
+/**
+ * parse a tel uri or a number as typed by a person; a text that can not be parsed is kept and the value is invalid
+ */
+func (v *TelValue) SetValue(s string) {
+	var err error
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToLower(s), "tel:") {
+		v.Number, v.Extension, v.params, err = parseTelUri(s[len("tel:"):])
+	} else {
+		v.params = ""
+		v.Number, v.Extension, err = parseTelText(s)
+	}
+	v.raw = ""
+	if err != nil {
+		v.Number, v.Extension, v.params, v.raw = "", "", "", s
+	}
+}
+
+/**
+ * the tel uri of a global number, the text of a local number (vcard 4.0)
+ */
+func (v *TelValue) GetValue() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	if v.IsGlobal() {
+		return v.URI()
+	}
+	return v.text()
+}
+
+func (v *TelValue) IsEmpty() bool {
+	return v.raw == "" && v.Number == ""
+}
+
+func (v *TelValue) GetString() string {
+	return EscapeText(v.GetValue())
+}
+
+/**
+ * tel uri for vcard 4.0 (local numbers excepted, a tel uri needs their phone-context), text for vcard 2.1 and 3.0
+ */
+func (v *TelValue) GetVersionedString(version string) string {
+	if version == "4.0" {
+		return v.GetString()
+	}
+	return EscapeText(v.Text())
+}
+
+/**
+ * the number has a country calling code
+ */
+func (v *TelValue) IsGlobal() bool {
+	return v.raw == "" && strings.HasPrefix(v.Number, "+")
+}
+
+/**
+ * the E.164 form of a global number (+15551234567), the extension excluded; empty for the local and invalid numbers
+ * two values with the same E.164 number are the same line
+ */
+func (v *TelValue) E164() string {
+	if !v.IsGlobal() || !v.Validate() {
+		return ""
+	}
+	return v.Number
+}
+
+/**
+ * RFC 3966 uri: tel:+15551234567;ext=89
+ */
+func (v *TelValue) URI() string {
+	var s strings.Builder
+	s.WriteString("tel:")
+	s.WriteString(v.Number)
+	if v.Extension != "" {
+		s.WriteString(";ext=")
+		s.WriteString(v.Extension)
+	}
+	s.WriteString(v.params)
+	return s.String()
+}
+
+/**
+ * the number as text (+15551234567 ext. 89); the text given to SetValue for an invalid value
+ */
+func (v *TelValue) Text() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	return v.text()
+}
+
+func (v *TelValue) text() string {
+	if v.Extension == "" {
+		return v.Number
+	}
+	return v.Number + " ext. " + v.Extension
+}
+
+/**
+ * digits of a number, with the "+" prefix for the global numbers; the international prefix "00" is replaced by "+"
+ * the separators (space, - . / ( )) are dropped and the letters are converted with the phone keypad (1-800-FLOWERS)
+ */
+func telDigits(s string) (string, error) {
+	var digits strings.Builder
+	global := false
+	for i, c := range s {
+		switch {
+			case c >= '0' && c <= '9':
+				digits.WriteRune(c)
+			case c == '+' && digits.Len() == 0 && !global:
+				global = true
+			case strings.ContainsRune(" \t-./()[]", c):
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+				if digits.Len() == 0 {
+					return "", fmt.Errorf("vcard: unexpected %q at position %d in phone number", c, i)
+				}
+				digits.WriteByte(keypadDigit(c))
+			default:
+				return "", fmt.Errorf("vcard: unexpected %q at position %d in phone number", c, i)
+		}
+	}
+
+	number := digits.String()
+	if number == "" {
+		return "", fmt.Errorf("vcard: phone number without digits")
+	}
+	if global {
+		return "+" + number, nil
+	}
+	if strings.HasPrefix(number, "00") && len(number) > 2 {
+		return "+" + number[2:], nil
+	}
+	return number, nil
+}
+
+/**
+ * digit of a letter on the phone keypad (ITU E.161)
+ */
+func keypadDigit(c rune) byte {
+	const keypad = "22233344455566677778889999"
+	if c >= 'a' {
+		c -= 'a' - 'A'
+	}
+	return keypad[c-'A']
+}
+
+/**
+ * extension markers of the human input, longest first
+ */
+var telExtensionMarkers = []string{"extension", "ext.", "ext", "x", "#", ",", ";"}
+
+/**
+ * "+1 (555) 123-4567 ext. 89": the extension starts with a marker, the letters before it are vanity letters
+ */
+func parseTelText(s string) (string, string, error) {
+	main, ext := s, ""
+	lower := strings.ToLower(s)
+	for i := 0; i < len(lower); i++ {
+		marker := ""
+		for _, m := range telExtensionMarkers {
+			if strings.HasPrefix(lower[i:], m) {
+				marker = m
+				break
+			}
+		}
+		if marker == "" {
+			continue
+		}
+		rest := strings.TrimSpace(s[i+len(marker):])
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "."), ":")
+		rest = strings.TrimSuffix(strings.TrimSpace(rest), "#")
+		if isDigits(strings.TrimSpace(rest)) {
+			main, ext = s[:i], strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	number, err := telDigits(main)
+	if err != nil {
+		return "", "", err
+	}
+	return number, ext, nil
+}
+
+/**
+ * tel uri without its scheme
+ *   telephone-subscriber = global-number / local-number
+ *   global-number        = "+" *phonedigit DIGIT *phonedigit *par
+ *   local-number         = 1*phonedigit-hex *par context *par
+ *   par                  = ";" ("ext=" 1*phonedigit / "isub=" 1*uric / pname ["=" pvalue])
+ * a local number with a global phone-context (";phone-context=+44") is made global
+ */
+func parseTelUri(s string) (string, string, string, error) {
+	parts := strings.Split(s, ";")
+	number, err := telDigits(parts[0])
+	if err != nil || strings.IndexFunc(parts[0], isTelLetter) >= 0 {
+		return "", "", "", fmt.Errorf("vcard: invalid tel uri %q", s)
+	}
+
+	var (
+		ext string
+		params strings.Builder
+	)
+	for _, par := range parts[1:] {
+		name, value := par, ""
+		if i := strings.IndexByte(par, '='); i >= 0 {
+			name, value = par[:i], par[i+1:]
+		}
+		switch (strings.ToLower(name)) {
+			case "ext":
+				if ext, err = telDigits(value); err != nil || strings.HasPrefix(ext, "+") {
+					return "", "", "", fmt.Errorf("vcard: invalid extension in tel uri %q", s)
+				}
+			case "phone-context":
+				if strings.HasPrefix(value, "+") && !strings.HasPrefix(number, "+") {
+					context, err := telDigits(value)
+					if err != nil {
+						return "", "", "", fmt.Errorf("vcard: invalid phone-context in tel uri %q", s)
+					}
+					number = context + number
+					continue
+				}
+				params.WriteString(";" + par)
+			case "":
+			default:
+				params.WriteString(";" + par)
+		}
+	}
+	return number, ext, params.String(), nil
+}
+
+/**
+ * letters are not phone digits in a tel uri
+ */
+func isTelLetter(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * TYPE values of a TEL property that are in TelTypes, lower case
+ */
+func TelTypesOf(p IProperty) []string {
+	var result []string
+	if param := p.GetParameter("TYPE"); param != nil {
+		for _, v := range param.GetValue() {
+			v = strings.ToLower(v)
+			for _, t := range TelTypes {
+				if v == t {
+					result = append(result, v)
+				}
+			}
+		}
+	}
+	return result
+}
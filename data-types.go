@@ -2,8 +2,7 @@ package vcard
 
 import (
 	"strings"
-	"regexp"
-	"net/http"
+	"strconv"
 )
 
 /*
@@ -26,14 +25,158 @@ is, "\n" or "\N".
 GetString returns the string of the value structure. If the is compound, it creates the string joing components by ";" and escape  ";" char from components' value
 */
 
+/**
+ * escape a TEXT value (RFC 6350 3.4): backslash, comma and line breaks (CRLF, CR and LF are written as \n)
+ * the semicolon is not escaped: it is escaped only in the components of the structured values (see EscapeComponent)
+ * and in vcard 3.0 (RFC 2426 5, see Builder.RenderPropertyValue)
+ */
+func EscapeText(v string) string {
+	return escapeText(v, false)
+}
+
+/**
+ * escape a component (or a value of a component) of a structured value: as EscapeText, and the semicolon
+ */
+func EscapeComponent(v string) string {
+	return escapeText(v, true)
+}
+
+/**
+ * escape a value for any position (text, list, component); same as EscapeComponent
+ */
 func EscapeValue(v string) string {
-	v = strings.ReplaceAll(v, "\\", "\\\\")
-	v = strings.ReplaceAll(v, ",", "\\,")
-	v = strings.ReplaceAll(v, "\\n", "\\\\n")
+	return escapeText(v, true)
+}
 
-	// this is optional for value without componets
-	v = strings.ReplaceAll(v, ";", "\\;")
-	return v
+/**
+ * escape the values of a text list (CATEGORIES, NICKNAME) and join them with ","
+ */
+func EscapeTextList(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = EscapeText(v)
+	}
+	return strings.Join(escaped, ",")
+}
+
+/**
+ * escape a structured value (N, ADR): the components are joined with ";", the values of a component with ","
+ */
+func EscapeStructured(components [][]string) string {
+	var s strings.Builder
+	for i, c := range components {
+		if i > 0 {
+			s.WriteString(";")
+		}
+		for j, v := range c {
+			if j > 0 {
+				s.WriteString(",")
+			}
+			s.WriteString(EscapeComponent(v))
+		}
+	}
+	return s.String()
+}
+
+func escapeText(v string, semicolon bool) string {
+	if !strings.ContainsAny(v, "\\,;\r\n") {
+		return v
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		switch (v[i]) {
+			case '\\':
+				s.WriteString("\\\\")
+			case ',':
+				s.WriteString("\\,")
+			case ';':
+				if semicolon {
+					s.WriteString("\\;")
+				} else {
+					s.WriteByte(';')
+				}
+			case '\r':
+				if i+1 < len(v) && v[i+1] == '\n' {
+					i++
+				}
+				s.WriteString("\\n")
+			case '\n':
+				s.WriteString("\\n")
+			default:
+				s.WriteByte(v[i])
+		}
+	}
+	return s.String()
+}
+
+/**
+ * reverse of EscapeText, EscapeComponent and EscapeValue: "\\", "\,", "\;" are replaced by the escaped char and "\n" / "\N" by a new line
+ */
+func UnescapeValue(v string) string {
+	if !strings.Contains(v, "\\") {
+		return v
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) {
+			i++
+			switch (v[i]) {
+				case 'n', 'N':
+					s.WriteByte('\n')
+				default:
+					s.WriteByte(v[i])
+			}
+			continue
+		}
+		s.WriteByte(v[i])
+	}
+	return s.String()
+}
+
+/**
+ * reverse of EscapeTextList
+ */
+func UnescapeTextList(v string) []string {
+	var result []string
+	for _, item := range splitEscaped(v, ',') {
+		result = append(result, UnescapeValue(item))
+	}
+	return result
+}
+
+/**
+ * reverse of EscapeStructured
+ */
+func UnescapeStructured(v string) [][]string {
+	var result [][]string
+	for _, c := range splitEscaped(v, ';') {
+		result = append(result, UnescapeTextList(c))
+	}
+	return result
+}
+
+/**
+ * split a raw (escaped) value by sep, ignoring the escaped separators
+ * the returned parts are not unescaped
+ */
+func splitEscaped(v string, sep byte) []string {
+	var (
+		result []string
+		start int
+	)
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+			case '\\':
+				// skip the escaped char
+				i++
+			case sep:
+				result = append(result, v[start:i])
+				start = i + 1
+		}
+	}
+	return append(result, v[start:])
 }
 
 
@@ -58,7 +201,7 @@ func (v *TextValue) IsEmpty() bool {
 }
 
 func (v *TextValue) GetString() string {
-	return EscapeValue(v.value)
+	return EscapeText(v.value)
 }
 
 
@@ -101,10 +244,10 @@ func (v *GenderValue) Validate() bool {
 
 func (v *GenderValue) GetString() string {
 	var s strings.Builder
-	s.WriteString(EscapeValue(v.Sex))
+	s.WriteString(EscapeComponent(v.Sex))
 	if len(v.Identity) > 0 {
 		s.WriteString(";")
-		s.WriteString(EscapeValue(v.Identity))
+		s.WriteString(EscapeComponent(v.Identity))
 	}
 	return s.String()
 }
@@ -152,46 +295,254 @@ func NewGender(s string, i string) *GenderValue {
 			  p-unreserved  = "[" / "]" / ":" / "&" / "+" / "$"
 			  alphanum      = ALPHA / DIGIT
   */
- type GeoValue struct {
-	 *TextValue
-	 Lat  string //;
-	 Lon string
-	 Alt string
-	 P string
- }
+type GeoValue struct {
+	*TextValue
+	Lat  string //;
+	Lon string
+	Alt string
 
- func (v *GeoValue) GetType() string {
-	 return "GEO"
- }
+	// geo uri parameters, with their leading ";" (";crs=wgs84;u=35")
+	P string
+}
 
- func (v *GeoValue) Validate() bool {
-	 var result = false
+func (v *GeoValue) GetType() string {
+	return "GEO"
+}
 
-	 return result
- }
+/**
+ * latitude between -90 and 90, longitude between -180 and 180, optional altitude; all decimal numbers
+ * with another crs than wgs84 the ranges are unknown and only the numbers are checked; the uncertainty is a positive number
+ */
+func (v *GeoValue) Validate() bool {
+	lat, err := strconv.ParseFloat(v.Lat, 64)
+	if err != nil {
+		return false
+	}
+	lon, err := strconv.ParseFloat(v.Lon, 64)
+	if err != nil {
+		return false
+	}
+	if v.Alt != "" {
+		if _, err := strconv.ParseFloat(v.Alt, 64); err != nil {
+			return false
+		}
+	}
+	if u, ok := v.geoParameter("u"); ok {
+		if f, err := strconv.ParseFloat(u, 64); err != nil || f < 0 {
+			return false
+		}
+	}
+	if v.CRS() != "wgs84" {
+		return true
+	}
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+/**
+ * parse a geo uri (geo:lat,lon[,alt][;params]), a vcard 3.0 "lat;lon" value or a vcard 2.1 "lat,lon" value
+ */
+func (v *GeoValue) SetValue(s string) {
+	s = strings.TrimSpace(s)
+	v.Lat, v.Lon, v.Alt, v.P = "", "", "", ""
+	if strings.HasPrefix(strings.ToLower(s), "geo:") {
+		path := s[len("geo:"):]
+		if i := strings.IndexByte(path, ';'); i >= 0 {
+			v.P = path[i:]
+			path = path[:i]
+		}
+		coords := strings.Split(path, ",")
+		v.Lat = coords[0]
+		if len(coords) > 1 {
+			v.Lon = coords[1]
+		}
+		if len(coords) > 2 {
+			v.Alt = coords[2]
+		}
+		return
+	}
+
+	sep := ";"
+	if !strings.Contains(s, sep) {
+		sep = ","
+	}
+	coords := strings.SplitN(s, sep, 2)
+	v.Lat = strings.TrimSpace(coords[0])
+	if len(coords) > 1 {
+		v.Lon = strings.TrimSpace(coords[1])
+	}
+}
 
- func (v *GeoValue) GetString() string {
-	 var s strings.Builder
+/**
+ * the geo uri
+ */
+func (v *GeoValue) GetValue() string {
+	var s strings.Builder
 
-	 s.WriteString("geo:")
-	 s.WriteString(EscapeValue(v.Lat))
-	 s.WriteString(",")
-	 s.WriteString(EscapeValue(v.Lon))
-	 if len(v.Alt) > 0 {
+	s.WriteString("geo:")
+	s.WriteString(v.Lat)
+	s.WriteString(",")
+	s.WriteString(v.Lon)
+	if len(v.Alt) > 0 {
 		s.WriteString(",")
-		s.WriteString(EscapeValue(v.Alt))
-	 }
+		s.WriteString(v.Alt)
+	}
+	s.WriteString(v.P)
 
-	 return s.String()
- }
+	return s.String()
+}
 
- func NewGeo(lat string, lon string, alt string) *GeoValue {
-	 return &GeoValue {
-		 Lat: lat,
-		 Lon: lon,
-		 Alt: alt,
-	 }
- }
+func (v *GeoValue) IsEmpty() bool {
+	return v.Lat == "" && v.Lon == "" && v.Alt == ""
+}
+
+/**
+ * uris are not escaped
+ */
+func (v *GeoValue) GetString() string {
+	return v.GetValue()
+}
+
+/**
+ * geo uri for vcard 4.0, "lat;lon" for vcard 3.0 (RFC 2426 3.4.2) and "lat,lon" for vcard 2.1;
+ * the altitude and the parameters are only written in the uri
+ */
+func (v *GeoValue) GetVersionedString(version string) string {
+	switch (version) {
+		case "3.0":
+			return EscapeComponent(v.Lat) + ";" + EscapeComponent(v.Lon)
+		case "2.1":
+			return v.Lat + "," + v.Lon
+	}
+	return v.GetString()
+}
+
+/**
+ * coordinate reference system, lower case; "wgs84" if not set
+ */
+func (v *GeoValue) CRS() string {
+	if crs, ok := v.geoParameter("crs"); ok {
+		return strings.ToLower(crs)
+	}
+	return "wgs84"
+}
+
+/**
+ * uncertainty of the location, in meters; false if not set or invalid
+ */
+func (v *GeoValue) Uncertainty() (float64, bool) {
+	u, ok := v.geoParameter("u")
+	if !ok {
+		return 0, false
+	}
+	return parseGeoFloat(u)
+}
+
+func (v *GeoValue) SetCRS(crs string) {
+	v.setGeoParameter("crs", crs)
+}
+
+/**
+ * uncertainty in meters; a negative value removes the parameter
+ */
+func (v *GeoValue) SetUncertainty(u float64) {
+	if u < 0 {
+		v.setGeoParameter("u", "")
+		return
+	}
+	v.setGeoParameter("u", formatGeoFloat(u))
+}
+
+func (v *GeoValue) Latitude() (float64, bool) {
+	return parseGeoFloat(v.Lat)
+}
+
+func (v *GeoValue) Longitude() (float64, bool) {
+	return parseGeoFloat(v.Lon)
+}
+
+/**
+ * altitude in meters; false if not set
+ */
+func (v *GeoValue) Altitude() (float64, bool) {
+	return parseGeoFloat(v.Alt)
+}
+
+/**
+ * value of a geo uri parameter (case insensitive name)
+ */
+func (v *GeoValue) geoParameter(name string) (string, bool) {
+	for _, param := range strings.Split(v.P, ";") {
+		pname, pvalue := param, ""
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			pname, pvalue = param[:i], param[i+1:]
+		}
+		if strings.EqualFold(pname, name) {
+			return pvalue, true
+		}
+	}
+	return "", false
+}
+
+/**
+ * set or remove (empty value) a geo uri parameter; crs and u are kept first, in this order (RFC 5870 3.3)
+ */
+func (v *GeoValue) setGeoParameter(name string, value string) {
+	values := map[string]string{}
+	for _, known := range []string{"crs", "u"} {
+		if pvalue, ok := v.geoParameter(known); ok {
+			values[known] = pvalue
+		}
+	}
+	values[name] = value
+
+	var s strings.Builder
+	for _, known := range []string{"crs", "u"} {
+		if values[known] != "" {
+			s.WriteString(";" + known + "=" + values[known])
+		}
+	}
+	for _, param := range strings.Split(v.P, ";") {
+		pname := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			pname = param[:i]
+		}
+		if param == "" || strings.EqualFold(pname, "crs") || strings.EqualFold(pname, "u") {
+			continue
+		}
+		s.WriteString(";" + param)
+	}
+	v.P = s.String()
+}
+
+func parseGeoFloat(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+/**
+ * shortest decimal form, without exponent
+ */
+func formatGeoFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func NewGeo(lat string, lon string, alt string) *GeoValue {
+	return &GeoValue {
+		Lat: lat,
+		Lon: lon,
+		Alt: alt,
+	}
+}
+
+/**
+ * value with a latitude and a longitude in decimal degrees (wgs84)
+ */
+func NewGeoFromFloats(lat float64, lon float64) *GeoValue {
+	return NewGeo(formatGeoFloat(lat), formatGeoFloat(lon), "")
+}
 
 
  /**
@@ -199,6 +550,10 @@ func NewGender(s string, i string) *GenderValue {
  */
 
 
+/**
+ * a component with more values (ADR:;;1 Main St,Apt 2;...) keeps them separated by commas: a comma in a component
+ * is a list separator (see Components)
+ */
 type AddressValue struct {
 	*TextValue
 	Pobox  string //the post office box;
@@ -223,22 +578,40 @@ func (v *AddressValue) IsEmpty() bool {
 }
 
 func (v *AddressValue) GetString() string {
-	var s strings.Builder
-	s.WriteString(EscapeValue(v.Pobox))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.Ext))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.Street))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.Locality))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.Region))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.PostalCode))
-	s.WriteString(";")
-	s.WriteString(EscapeValue(v.Country))
+	return EscapeStructured(v.Components())
+}
 
-	return s.String()
+/**
+ * the 7 components, each one a list of values
+ */
+func (v *AddressValue) Components() [][]string {
+	return [][]string{
+		splitComponent(v.Pobox),
+		splitComponent(v.Ext),
+		splitComponent(v.Street),
+		splitComponent(v.Locality),
+		splitComponent(v.Region),
+		splitComponent(v.PostalCode),
+		splitComponent(v.Country),
+	}
+}
+
+/**
+ * set the components from lists of values; the missing components are emptied
+ */
+func (v *AddressValue) SetComponents(components [][]string) {
+	var c [7]string
+	for i := 0; i < len(components) && i < 7; i++ {
+		c[i] = strings.Join(components[i], ",")
+	}
+	v.Pobox, v.Ext, v.Street, v.Locality, v.Region, v.PostalCode, v.Country = c[0], c[1], c[2], c[3], c[4], c[5], c[6]
+}
+
+func splitComponent(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
 }
 
 func NewAddress() *AddressValue {
@@ -318,7 +691,7 @@ func (v *NameValue) GetString() string {
 		if idx != 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(EscapeValue(vs))
+		s.WriteString(EscapeComponent(vs))
 	}
 	s.WriteString(";")
 
@@ -326,7 +699,7 @@ func (v *NameValue) GetString() string {
 		if idx != 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(EscapeValue(vs))
+		s.WriteString(EscapeComponent(vs))
 	}
 	s.WriteString(";")
 
@@ -334,7 +707,7 @@ func (v *NameValue) GetString() string {
 		if idx != 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(EscapeValue(vs))
+		s.WriteString(EscapeComponent(vs))
 	}
 	s.WriteString(";")
 
@@ -342,7 +715,7 @@ func (v *NameValue) GetString() string {
 		if idx != 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(EscapeValue(vs))
+		s.WriteString(EscapeComponent(vs))
 	}
 	s.WriteString(";")
 
@@ -350,7 +723,7 @@ func (v *NameValue) GetString() string {
 		if idx != 0 {
 			s.WriteString(",")
 		}
-		s.WriteString(EscapeValue(vs))
+		s.WriteString(EscapeComponent(vs))
 	}
 
 	return s.String()
@@ -398,14 +771,14 @@ func NewName() *NameValue {
  func (v *OrganizationValue) GetString() string {
 	var s strings.Builder
 
-	s.WriteString(EscapeValue(v.Company))
+	s.WriteString(EscapeComponent(v.Company))
 	if len(v.Departments) > 0 {
 		s.WriteString(";")
 		for idx, vs := range v.Departments {
 			if idx != 0 {
 				s.WriteString(";")
 			}
-			s.WriteString(EscapeValue(vs))
+			s.WriteString(EscapeComponent(vs))
 		}
 	}
 
@@ -419,86 +792,52 @@ func NewName() *NameValue {
 	 }
 }
 
- /**
- *  photo value
+/**
+ * generic structured value: components separated by ";", each component a list separated by ","
+ * used by the structured properties without a dedicated type (CLIENTPIDMAP, registered X- properties)
  */
- type PhotoValue struct {
+type StructuredValue struct {
 	*TextValue
-	IsUrl  bool // if the value is an url;
-	IsB64Encoded bool // if the value is a base64 encoded string
-	MediaType string // mime type of the value
+	Components [][]string
 }
 
-func (v *PhotoValue) IsEmpty() bool {
-	return v.value == ""
+func (v *StructuredValue) GetType() string {
+	return "STRUCTURED"
 }
 
-func (v *PhotoValue) GetType() string {
-	return "PHOTO"
+func (v *StructuredValue) Validate() bool {
+	return true
 }
 
-func (v *PhotoValue) Validate() bool {
+func (v *StructuredValue) IsEmpty() bool {
+	for _, c := range v.Components {
+		for _, s := range c {
+			if s != "" {
+				return false
+			}
+		}
+	}
 	return true
 }
 
 /**
- *  detect if a value is a Data URI to decompose the uri,  an URI, or a simple file string
- */
-func (v *PhotoValue) AutodetectValue(s string) {
-	if (IsUri(s)) {
-		// the value it's an url or something like data:image/jpeg;base64, xxxx
-		re := regexp.MustCompile(`^data\:([^\:;]+)?(;base64)?,(.*)`)
-		tmp := re.FindStringSubmatch(s)
-
-		if len(tmp) == 0 {
-			// it not a data URI
-			v.IsUrl = true
-			v.IsB64Encoded = false //IsBase64Encoded(s)
-			v.SetValue(s)
-			v.MediaType = "" //http.DetectContentType([]byte(s))
-		} else {
-			/*
-				 tmp -> 0-> full match
-						 1 -> media type
-						 2 -> base64
-						 3 -> file content
-				it's possible that 1 and/or 2 to be missing
-			*/
-			// it's a data schema
-			fileContent := tmp[len(tmp)-1]
-			if len(tmp) > 2 {
-				// we have at least media type or base64
-				if strings.ToLower(tmp[1]) == ";base64" || (len(tmp)>3 && strings.ToLower(tmp[2]) == ";base64") {
-					// media type is missing or all elements are present
-					v.IsB64Encoded = true
-				}
-				if strings.ToLower(tmp[1]) != ";base64" {
-					v.MediaType = tmp[1]
-				}
-			}
-			if v.MediaType == "" {
-				v.MediaType = http.DetectContentType([]byte(fileContent))
-			}
-
-			v.SetValue(fileContent)
-		}
-	} else {
-		v.IsUrl = false
-		v.IsB64Encoded = IsBase64Encoded(s)
-		v.SetValue(s)
-		v.MediaType = http.DetectContentType([]byte(s))
+ * the components, unescaped and separated by ";"
+ */
+func (v *StructuredValue) GetValue() string {
+	var parts []string
+	for _, c := range v.Components {
+		parts = append(parts, strings.Join(c, ","))
 	}
+	return strings.Join(parts, ";")
+}
+
+func (v *StructuredValue) GetString() string {
+	return EscapeStructured(v.Components)
 }
 
-func NewPhoto(s string) *PhotoValue {
-	p := &PhotoValue {
+func NewStructured(components [][]string) *StructuredValue {
+	return &StructuredValue {
 		TextValue: &TextValue{},
-		IsUrl: false,
-		IsB64Encoded: false,
-		MediaType: "", // mime type of the value
-	}
-	if len(s)>0 {
-		p.AutodetectValue(s)
+		Components: components,
 	}
-	return p
-}
\ No newline at end of file
+}
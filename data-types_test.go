@@ -0,0 +1,89 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeoValue(t *testing.T) {
+	tests := []struct {
+		in string
+		lat, lon float64
+		valid bool
+	}{
+		{"geo:37.386013,-122.082932", 37.386013, -122.082932, true},
+		{"geo:37.386013,-122.082932,12;u=35", 37.386013, -122.082932, true},
+		{"37.386013;-122.082932", 37.386013, -122.082932, true},
+		{"37.386013,-122.082932", 37.386013, -122.082932, true},
+		{"geo:91,0", 91, 0, false},
+		{"geo:0,181", 0, 181, false},
+		{"geo:0,0;u=-1", 0, 0, false},
+		{"geo:200,300;crs=other", 200, 300, true},
+	}
+	for _, tt := range tests {
+		v := NewGeo("", "", "")
+		v.SetValue(tt.in)
+		lat, ok1 := v.Latitude()
+		lon, ok2 := v.Longitude()
+		if !ok1 || !ok2 || lat != tt.lat || lon != tt.lon {
+			t.Errorf("%s: %v %v", tt.in, lat, lon)
+		}
+		if v.Validate() != tt.valid {
+			t.Errorf("%s: Validate() = %v", tt.in, !tt.valid)
+		}
+	}
+
+	v := NewGeo("", "", "")
+	v.SetValue("geo:37.786971,-122.399677;crs=WGS84;u=35")
+	if u, ok := v.Uncertainty(); !ok || u != 35 || v.CRS() != "wgs84" {
+		t.Errorf("parameters: %v %v %q", u, ok, v.CRS())
+	}
+	if alt, ok := v.Altitude(); ok {
+		t.Errorf("altitude %v", alt)
+	}
+}
+
+func TestGeoVersionedString(t *testing.T) {
+	v := NewGeoFromFloats(37.786971, -122.399677)
+	v.SetUncertainty(35)
+	tests := map[string]string{
+		"4.0": "geo:37.786971,-122.399677;u=35",
+		"3.0": "37.786971;-122.399677",
+		"2.1": "37.786971,-122.399677",
+	}
+	for version, want := range tests {
+		if got := v.GetVersionedString(version); got != want {
+			t.Errorf("%s: %q, want %q", version, got, want)
+		}
+	}
+
+	// GEO is written in the form of each version and read back
+	for version, want := range tests {
+		card, _ := NewVCard(version)
+		geo := card.CreateProperty("GEO")
+		geo.AddValue(v)
+		card.AddProperty(geo)
+		out := card.Build()
+		if !strings.Contains(out, "\r\nGEO:"+want+"\r\n") {
+			t.Errorf("%s: %q", version, out)
+		}
+		back, err := Parse(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, ok := back.GetProperty("GEO")[0].GetFirstValue().(*GeoValue)
+		if lat, _ := g.Latitude(); !ok || lat != 37.786971 {
+			t.Errorf("%s: read back %+v", version, back.GetProperty("GEO")[0].GetFirstValue())
+		}
+	}
+
+	// the values made with NewGeo can be read and changed
+	g := NewGeo("1", "2", "")
+	if g.GetValue() != "geo:1,2" {
+		t.Errorf("NewGeo value %q", g.GetValue())
+	}
+	g.SetValue("3;4")
+	if g.GetString() != "geo:3,4" {
+		t.Errorf("NewGeo SetValue %q", g.GetString())
+	}
+}
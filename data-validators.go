@@ -44,6 +44,51 @@ func ValidateData(d IData, dataFormat string) bool {
 					return IsTimestamp(d.GetValue())
 			}
 			return true
+		case "PHOTO":
+			// media values: inline data or uri
+			photo, ok := d.(*MediaValue)
+			if !ok {
+				return false
+			}
+			switch (strings.ToUpper(dataFormat)) {
+				case "URI":
+					return photo.IsUrl || photo.IsDataUri
+				case "BINARY":
+					return photo.IsB64Encoded && !photo.IsDataUri
+				case "TEXT":
+					return true
+			}
+			return false
+		case "GEO":
+			// geo: uri (vcard 4.0) or latitude;longitude floats (vcard 3.0)
+			switch (strings.ToUpper(dataFormat)) {
+				case "URI", "FLOAT", "TEXT":
+					return d.Validate()
+			}
+			return false
+		case "EMAIL":
+			// email addresses are text values
+			return strings.ToUpper(dataFormat) == "TEXT" && d.Validate()
+		case "TEL":
+			// phone numbers: tel uri for the global numbers, text otherwise
+			tel, ok := d.(*TelValue)
+			if !ok || !tel.Validate() {
+				return false
+			}
+			switch (strings.ToUpper(dataFormat)) {
+				case "URI":
+					return tel.IsGlobal()
+				case "TEXT", "PHONE-NUMBER":
+					return true
+			}
+			return false
+		case "DATE", "TIME", "DATE-TIME", "DATE-AND-OR-TIME", "TIMESTAMP":
+			// the components are checked against the forms of the value type (ex: a DATE value is a valid DATE-AND-OR-TIME)
+			v, ok := d.(dateTimeData)
+			return ok && d.Validate() && v.validFor(strings.ToLower(dataFormat))
+		case "NAME", "ADDRESS", "ORG", "GENDER", "STRUCTURED":
+			// structured text values
+			return strings.ToUpper(dataFormat) == "TEXT"
 		default:
 			if strings.ToUpper(d.GetType()) == strings.ToUpper(dataFormat) {
 				return true
@@ -61,7 +106,7 @@ func IsUri(s string) bool {
 }
 
 func IsEmail(s string) bool {
-	if len(s) < 3 && len(s) > 254 {
+	if len(s) < 3 || len(s) > 254 {
 		return false
 	}
 	matched, _ := regexp.MatchString("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$", s)
@@ -130,7 +175,7 @@ func IsTime(s string) bool {
 
 
 func IsDatetime(s string) bool {
-	matched, _ := regexp.MatchString(`^([0-9]{4}|\-\-)([0-9]{2}|\-){2}T[0-9]{2}([0-9]{2}){0,2}$`, s)
+	matched, _ := regexp.MatchString(`^([0-9]{4}|\-\-)([0-9]{2}|\-){2}T[0-9]{2}([0-9]{2}){0,2}(Z|[\-+][0-9]{2}([0-9]{2})?)?$`, s)
 	return matched
 }
 
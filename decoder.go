@@ -0,0 +1,266 @@
+/**
+ * streaming reader for files with one or more vcards (BEGIN:VCARD ... END:VCARD blocks)
+ */
+package vcard
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+type Decoder struct {
+	lineReader
+
+	// VERSION of the card being read, "" until its VERSION line
+	version string
+
+	// last decoded card and error, used by Next/Card/Err
+	card IVCard
+	err error
+}
+
+/**
+ * reader of physical lines, joined into logical lines (used by the Decoder and by Unfold)
+ */
+type lineReader struct {
+	r *bufio.Reader
+
+	// number of physical lines read so far
+	line int
+
+	// physical line read ahead while unfolding
+	pending *physicalLine
+}
+
+/**
+ * a physical line and its line ending: "\r\n" or "\n", "" or a lone "\r" for the last line of the input
+ */
+type physicalLine struct {
+	text string
+	ending string
+}
+
+/**
+ * read a logical line, unfolding the continuation lines:
+ *  - lines starting with a space or a tab
+ *  - lines following a quoted-printable soft line break ("=" at the end of the line), for the ENCODING=QUOTED-PRINTABLE values
+ *  - vcard 2.1 base64 lines that are not indented (the value ends with an empty line)
+ * return the line and the physical line number where it starts
+ */
+func (d *Decoder) readLine() (string, int, error) {
+	var (
+		parsed bool
+		quotedPrintable bool
+		base64 bool
+	)
+
+	l, _, start, err := d.readLogicalLine(func(l string, next string) (string, bool) {
+		if !parsed {
+			// the ENCODING parameter is known once the header is complete (it may be folded too)
+			if cl, err := parseContentLine(l, 0); err == nil {
+				parsed = true
+				for _, enc := range cl.paramValues("ENCODING") {
+					switch (strings.ToUpper(enc)) {
+						case "QUOTED-PRINTABLE":
+							quotedPrintable = true
+						case "BASE64", "B":
+							base64 = d.version == "2.1"
+					}
+				}
+			}
+		}
+
+		switch {
+			case quotedPrintable && strings.HasSuffix(l, "="):
+				return l[:len(l)-1] + next, true
+			case base64 && next != "" && !isContinuationLine(next) && !strings.Contains(next, ":"):
+				return l + next, true
+		}
+		return "", false
+	})
+
+	return l, start, err
+}
+
+/**
+ * read a logical line: the physical lines starting with a space or a tab are appended to the previous one,
+ * without the line break and the space
+ * join may append other lines (it returns the joined line and true)
+ * return the line, the line ending of its last physical line and the physical line number where it starts
+ */
+func (r *lineReader) readLogicalLine(join func(l string, next string) (string, bool)) (string, string, int, error) {
+	p, err := r.readPhysicalLine()
+	if err != nil {
+		return "", "", 0, err
+	}
+	start := r.line
+	l, ending := p.text, p.ending
+
+	for {
+		next, err := r.peekPhysicalLine()
+		if err != nil {
+			break
+		}
+
+		if joined, ok := join(l, next.text); ok {
+			l = joined
+		} else if isContinuationLine(next.text) {
+			l += next.text[1:]
+		} else {
+			break
+		}
+		ending = next.ending
+		r.readPhysicalLine()
+	}
+
+	return l, ending, start, nil
+}
+
+/**
+ * read a physical line
+ */
+func (r *lineReader) readPhysicalLine() (physicalLine, error) {
+	p, err := r.peekPhysicalLine()
+	if err != nil {
+		return physicalLine{}, err
+	}
+	r.pending = nil
+	r.line++
+	return p, nil
+}
+
+/**
+ * read the next physical line without consuming it
+ */
+func (r *lineReader) peekPhysicalLine() (physicalLine, error) {
+	if r.pending != nil {
+		return *r.pending, nil
+	}
+
+	l, err := r.r.ReadString('\n')
+	if err != nil && (err != io.EOF || l == "") {
+		return physicalLine{}, err
+	}
+
+	p := physicalLine{text: l}
+	switch {
+		case strings.HasSuffix(l, "\r\n"):
+			p.text, p.ending = l[:len(l)-2], "\r\n"
+		case strings.HasSuffix(l, "\n"):
+			p.text, p.ending = l[:len(l)-1], "\n"
+		case strings.HasSuffix(l, "\r"):
+			p.text, p.ending = l[:len(l)-1], "\r"
+	}
+	r.pending = &p
+	return p, nil
+}
+
+func newLineReader(r io.Reader) lineReader {
+	return lineReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+/**
+ * read the next card
+ * return io.EOF when there are no more cards; on a *ParseError the rest of the broken card is skipped,
+ * so Decode may be called again to read the following cards
+ */
+func (d *Decoder) Decode() (IVCard, error) {
+	var (
+		lines []*contentLine
+		started bool
+		parseErr error
+	)
+
+	for {
+		l, lineNo, err := d.readLine()
+		if err == io.EOF {
+			if !started {
+				return nil, io.EOF
+			}
+			return nil, &ParseError{Line: d.line, Msg: "missing END:VCARD"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		cl, err := parseContentLine(l, lineNo)
+		if err != nil {
+			if !started {
+				return nil, err
+			}
+			// keep the first error and skip to the end of the card
+			if parseErr == nil {
+				parseErr = err
+			}
+			continue
+		}
+
+		if !started {
+			if cl.name != "BEGIN" || !strings.EqualFold(strings.TrimSpace(cl.value), "VCARD") {
+				return nil, &ParseError{Line: cl.line, Msg: "expected BEGIN:VCARD"}
+			}
+			started = true
+			d.version = ""
+			continue
+		}
+
+		if cl.name == "VERSION" {
+			d.version = strings.TrimSpace(cl.value)
+		}
+
+		if cl.name == "END" {
+			if strings.EqualFold(strings.TrimSpace(cl.value), "VCARD") {
+				break
+			}
+			if parseErr == nil {
+				parseErr = &ParseError{Line: cl.line, Msg: "expected END:VCARD"}
+			}
+			continue
+		}
+		lines = append(lines, cl)
+	}
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return buildCard(lines)
+}
+
+/**
+ * read the next card; return false at the end of the input or on error (see Err)
+ * a *ParseError stops only the broken card: Next may be called again to read the following cards
+ */
+func (d *Decoder) Next() bool {
+	d.card, d.err = d.Decode()
+	if d.err == io.EOF {
+		d.err = nil
+		return false
+	}
+	return d.err == nil
+}
+
+/**
+ * return the card read by the last Next call
+ */
+func (d *Decoder) Card() IVCard {
+	return d.card
+}
+
+/**
+ * return the error that stopped the last Next call (nil at the end of the input)
+ */
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		lineReader: newLineReader(r),
+	}
+}
@@ -0,0 +1,167 @@
+package vcard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEncodingParameter(t *testing.T) {
+	// the header mentions QUOTED-PRINTABLE and BASE64 but the values are not encoded
+	s := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:John\r\n" +
+		"N:;John;;;\r\n" +
+		"X-NOTE;X-KIND=QUOTED-PRINTABLE:a=\r\n" +
+		"NOTE:BASE64\r\n" +
+		"X-ID;X-FORMAT=BASE64:abc\r\n" +
+		"ORG:Example\r\n" +
+		"END:VCARD\r\n"
+
+	card, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := card.GetProperty("X-NOTE"); len(ps) != 1 || ps[0].GetFirstValue().GetValue() != "a=" {
+		t.Errorf("X-NOTE: the soft line break is removed")
+	}
+	if ps := card.GetProperty("X-ID"); len(ps) != 1 || ps[0].GetFirstValue().GetValue() != "abc" {
+		t.Errorf("X-ID: the next line is joined")
+	}
+	if len(card.GetProperty("ORG")) != 1 {
+		t.Errorf("ORG is lost")
+	}
+}
+
+func TestDecodeBase64Lines(t *testing.T) {
+	data := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+	lines := data[:40] + "\r\n" + data[40:80] + "\r\n" + data[80:] + "\r\n"
+
+	// vcard 2.1: the base64 lines may not be indented
+	s := "BEGIN:VCARD\r\nVERSION:2.1\r\nN:;John;;;\r\nPHOTO;ENCODING=BASE64;TYPE=PNG:\r\n" + lines + "\r\nEND:VCARD\r\n"
+	card, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := card.GetProperty("PHOTO")
+	if len(ps) != 1 {
+		t.Fatalf("%d PHOTO properties", len(ps))
+	}
+	m, ok := ps[0].GetFirstValue().(*MediaValue)
+	if !ok {
+		t.Fatalf("PHOTO value %T", ps[0].GetFirstValue())
+	}
+	if b, err := m.Bytes(); err != nil || len(b) == 0 {
+		t.Errorf("PHOTO data: %v", err)
+	}
+
+	// vcard 3.0: a line that is not indented starts a new property
+	s = "BEGIN:VCARD\r\nVERSION:3.0\r\nN:;John;;;\r\nPHOTO;ENCODING=b;TYPE=PNG:" + data[:40] + "\r\n" + data[40:] + "\r\nEND:VCARD\r\n"
+	if _, err := Parse(s); err == nil {
+		t.Errorf("vcard 3.0: the base64 line is joined")
+	} else if !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("vcard 3.0: %v", err)
+	}
+}
+
+func TestDecoderContinuesAfterParseError(t *testing.T) {
+	s := "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:One\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Two\r\nEND:FOO\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nbroken line\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Three\r\nEND:VCARD\r\n"
+
+	d := NewDecoder(strings.NewReader(s))
+	var names []string
+	var lines []int
+	for {
+		if d.Next() {
+			names = append(names, d.Card().GetProperty("FN")[0].GetFirstValue().GetValue())
+			continue
+		}
+		if err, ok := d.Err().(*ParseError); ok {
+			lines = append(lines, err.Line)
+			continue
+		}
+		break
+	}
+
+	if d.Err() != nil {
+		t.Errorf("error at the end: %v", d.Err())
+	}
+	if strings.Join(names, ",") != "One,Three" {
+		t.Errorf("cards %v", names)
+	}
+	if len(lines) != 2 || lines[0] != 8 || lines[1] != 12 {
+		t.Errorf("parse errors at lines %v", lines)
+	}
+}
+
+/**
+ * reader that counts the octets read
+ */
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestDecoderStreamsCards(t *testing.T) {
+	var s strings.Builder
+	e := NewEncoder(&s)
+	for i := 0; i < 1000; i++ {
+		card := NewVCardV3()
+		fn := card.CreateProperty("FN")
+		fn.AddValue(NewText(fmt.Sprintf("Contact %d", i)))
+		card.AddProperty(fn)
+		n := card.CreateProperty("N")
+		name := NewName()
+		name.AddFamilyName(fmt.Sprintf("Contact %d", i))
+		n.AddValue(name)
+		card.AddProperty(n)
+		tel := card.CreateProperty("TEL")
+		tel.AddValue(NewText("+1 555 0100"))
+		card.AddPropertyParameter(tel, "TYPE", []string{"work", "voice"})
+		card.AddProperty(tel)
+		if err := e.Encode(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &countingReader{r: strings.NewReader(s.String())}
+	d := NewDecoder(r)
+	first, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.n >= s.Len()/2 {
+		t.Errorf("%d of %d octets read for the first card", r.n, s.Len())
+	}
+
+	// the cards are read back as they were written
+	var out strings.Builder
+	e = NewEncoder(&out)
+	e.Encode(first)
+	count := 1
+	for d.Next() {
+		e.Encode(d.Card())
+		count++
+	}
+	if d.Err() != nil {
+		t.Fatal(d.Err())
+	}
+	if count != 1000 {
+		t.Errorf("%d cards", count)
+	}
+	if out.String() != s.String() {
+		t.Errorf("the decoded cards are not encoded back unchanged")
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("Decode at the end: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+/**
+ * streaming writer for files with one or more vcards
+ */
+package vcard
+
+import (
+	"bufio"
+	"io"
+)
+
+type Encoder struct {
+	w *bufio.Writer
+
+	// builder options, applied to every encoded card (see Builder)
+	parameterOrder string
+	foldWidth int
+	lineEnding string
+	thumbnailOptions *ThumbnailOptions
+
+	// first write error; once set, Encode does nothing and returns it
+	err error
+}
+
+/**
+ * set the order of the rendered parameters (see Builder.SetParameterOrder)
+ */
+func (e *Encoder) SetParameterOrder(v string) {
+	e.parameterOrder = v
+}
+
+/**
+ * set the maximum width of the physical lines (see Builder.SetFoldWidth)
+ */
+func (e *Encoder) SetFoldWidth(v int) {
+	e.foldWidth = v
+}
+
+/**
+ * set the line break: "\r\n" or "\n" (see Builder.SetLineEnding)
+ */
+func (e *Encoder) SetLineEnding(v string) {
+	e.lineEnding = v
+}
+
+/**
+ * reduce the inline PHOTO and LOGO images of every card (see Builder.SetThumbnailOptions); nil keeps the options of the cards
+ */
+func (e *Encoder) SetThumbnailOptions(o *ThumbnailOptions) {
+	e.thumbnailOptions = o
+}
+
+/**
+ * write a card followed by a line break, so the cards can be written one after another
+ * the card is written property by property and flushed to the underlying writer before Encode returns
+ * the properties with an image that could not be reduced are not written and their errors are returned as ThumbnailErrors;
+ * unlike the write errors, they do not stop the next cards
+ */
+func (e *Encoder) Encode(card IVCard) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	b := NewBuilder(card)
+	b.SetParameterOrder(e.parameterOrder)
+	b.SetFoldWidth(e.foldWidth)
+	b.SetLineEnding(e.lineEnding)
+	if e.thumbnailOptions != nil {
+		b.SetThumbnailOptions(e.thumbnailOptions)
+	}
+
+	if _, err := b.WriteTo(e.w); err != nil {
+		e.err = err
+		return err
+	}
+	if _, err := e.w.WriteString(b.GetLineEnding()); err != nil {
+		e.err = err
+		return err
+	}
+	if err := e.w.Flush(); err != nil {
+		e.err = err
+		return err
+	}
+	if errs := b.GetThumbnailErrors(); len(errs) > 0 {
+		return ThumbnailErrors(errs)
+	}
+	return nil
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+		foldWidth: DefaultFoldWidth,
+	}
+}
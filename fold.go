@@ -0,0 +1,206 @@
+/**
+ * line folding (RFC 6350 3.2, RFC 2426 2.6):
+ *  Content lines SHOULD be folded to a maximum width of 75 octets, excluding the line
+ *  break. Multi-octet characters MUST remain contiguous.
+ *  A logical line MAY be continued on the next physical line anywhere
+ *  between two characters by inserting a CRLF immediately followed by a
+ *  single white space character (space (U+0020) or horizontal tab (U+0009))
+ */
+package vcard
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// default maximum width of a physical line, in octets, without the line break
+	DefaultFoldWidth = 75
+
+	// smallest width that can hold a continuation space and any utf-8 character
+	minFoldWidth = utf8.UTFMax + 1
+)
+
+/**
+ * fold a content line: every physical line has at most width octets (the leading space of the
+ * continuation lines included) and utf-8 sequences are never split
+ * width <= 0 disables folding; a positive width lower than 5 is raised to 5
+ */
+func Fold(s string, width int, lineEnding string) string {
+	if width <= 0 || len(s) <= width {
+		return s
+	}
+	if width < minFoldWidth {
+		width = minFoldWidth
+	}
+
+	var result strings.Builder
+	result.Grow(len(s) + len(s)/(width-1)*(len(lineEnding)+1))
+
+	f := newFoldWriter(&result, width, lineEnding)
+	f.fold(s)
+
+	return result.String()
+}
+
+/**
+ * fold a value that starts on a new physical line (ex: base64 data), all its lines are continuation lines
+ * with folding disabled the value is returned as it is
+ */
+func FoldOnNewLine(s string, width int, lineEnding string) string {
+	if width <= 0 || s == "" {
+		return s
+	}
+	return lineEnding + Fold(" "+s, width, lineEnding)
+}
+
+/**
+ * writer of content lines that folds them as they are written, so that a large value is not copied to be folded
+ * the parts of a line may be written with several calls: the line is folded as if it was written at once
+ * the first write error is kept and the next writes do nothing
+ */
+type foldWriter struct {
+	w io.Writer
+
+	// maximum width of the physical lines, 0 disables folding (see Fold)
+	width int
+	lineEnding string
+
+	// octets written on the current physical line, and the octets of its leading space (1 on the continuation lines)
+	col int
+	indent int
+
+	written int64
+	err error
+}
+
+func newFoldWriter(w io.Writer, width int, lineEnding string) *foldWriter {
+	if width > 0 && width < minFoldWidth {
+		width = minFoldWidth
+	}
+	return &foldWriter{
+		w: w,
+		width: width,
+		lineEnding: lineEnding,
+	}
+}
+
+/**
+ * write s on the current physical line, as it is
+ */
+func (f *foldWriter) write(s string) {
+	if f.err != nil || s == "" {
+		return
+	}
+	n, err := io.WriteString(f.w, s)
+	f.written += int64(n)
+	f.col += n
+	f.err = err
+}
+
+/**
+ * end the logical line
+ */
+func (f *foldWriter) newLine() {
+	f.write(f.lineEnding)
+	f.col, f.indent = 0, 0
+}
+
+/**
+ * start a continuation line: line break (unless at the start of a line) and a space
+ */
+func (f *foldWriter) continueLine() {
+	if f.col > 0 {
+		f.write(f.lineEnding)
+	}
+	f.col = 0
+	f.write(" ")
+	f.indent = 1
+}
+
+/**
+ * write s on the current logical line, folded
+ */
+func (f *foldWriter) fold(s string) {
+	if f.width <= 0 {
+		f.write(s)
+		return
+	}
+	for s != "" && len(s) > f.width-f.col {
+		cut := f.width - f.col
+		if cut < 0 {
+			cut = 0
+		}
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 && f.col <= f.indent {
+			// invalid utf-8, no sequence to keep contiguous
+			cut = f.width - f.col
+		}
+
+		f.write(s[:cut])
+		f.continueLine()
+		s = s[cut:]
+	}
+	f.write(s)
+}
+
+/**
+ * reverse of Fold: remove the line breaks (CRLF or LF) followed by a space or a tab
+ * the lines are joined as the Decoder joins them, the other line breaks are kept
+ */
+func Unfold(s string) string {
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+
+	var result strings.Builder
+	r := newLineReader(strings.NewReader(s))
+	noJoin := func(string, string) (string, bool) {
+		return "", false
+	}
+	for {
+		l, ending, _, err := r.readLogicalLine(noJoin)
+		if err != nil {
+			break
+		}
+		result.WriteString(l)
+		result.WriteString(ending)
+	}
+
+	return result.String()
+}
+
+/**
+ * a physical line that continues the previous one
+ */
+func isContinuationLine(l string) bool {
+	return l != "" && (l[0] == ' ' || l[0] == '\t')
+}
+
+/**
+ * large binary properties: the value is written on its own lines
+ */
+func isBinaryProperty(name string) bool {
+	switch (name) {
+		case "PHOTO", "LOGO", "SOUND", "KEY":
+			return true
+	}
+	return false
+}
+
+/**
+ * fold a content line with the default width and CRLF line breaks
+ */
+func FormatLine(s string) string {
+	return Fold(s, DefaultFoldWidth, "\r\n")
+}
+
+/**
+ * fold a value that starts on a new line, with the default width and CRLF line breaks
+ */
+func FormatSecondaryLines(s string) string {
+	return FoldOnNewLine(s, DefaultFoldWidth, "\r\n")
+}
@@ -0,0 +1,54 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFoldInvalidUtf8(t *testing.T) {
+	s := strings.Repeat("\x80", 200)
+
+	done := make(chan string, 1)
+	go func() {
+		done <- Fold(s, 75, "\r\n")
+	}()
+
+	var folded string
+	select {
+		case folded = <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Fold does not return on invalid utf-8")
+	}
+
+	for _, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("physical line of %d octets", len(line))
+		}
+	}
+	if Unfold(folded) != s {
+		t.Errorf("Unfold(Fold(s)) != s")
+	}
+}
+
+func TestBuildInvalidUtf8Note(t *testing.T) {
+	note := strings.Repeat("\x80", 80)
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:John\r\nN:;John;;;\r\nNOTE:" + note + "\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		done <- NewBuilder(card).Build()
+	}()
+
+	select {
+		case out := <-done:
+			if !strings.Contains(Unfold(out), "NOTE:"+note) {
+				t.Errorf("the note is not kept: %q", out)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Build does not return on invalid utf-8")
+	}
+}
@@ -0,0 +1,263 @@
+/**
+ * internationalized domain names: punycode (RFC 3492) and the label conversion of IDNA (RFC 5891)
+ * the labels are lower cased but not NFC normalized, nor checked against the IDNA 2008 code point tables
+ */
+package vcard
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+ * prefix of the punycode labels
+ */
+const idnaAcePrefix = "xn--"
+
+const (
+	punycodeBase = 36
+	punycodeTMin = 1
+	punycodeTMax = 26
+	punycodeSkew = 38
+	punycodeDamp = 700
+	punycodeInitialBias = 72
+	punycodeInitialN = 128
+
+	// the deltas are kept far from the int overflow (RFC 3492 6.4)
+	punycodeMaxInt = 1<<31 - 1
+
+	// utf-16 surrogates, not valid code points
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+var errPunycode = errors.New("vcard: invalid punycode")
+
+/**
+ * ASCII form of a domain (bücher.example -> xn--bcher-kva.example); the ASCII labels are only lower cased
+ */
+func DomainToASCII(domain string) (string, error) {
+	labels := splitDomain(domain)
+	for i, label := range labels {
+		label = strings.ToLower(label)
+		if !isASCII(label) {
+			encoded, err := punycodeEncode(label)
+			if err != nil {
+				return "", err
+			}
+			label = idnaAcePrefix + encoded
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, "."), nil
+}
+
+/**
+ * Unicode form of a domain (xn--bcher-kva.example -> bücher.example)
+ */
+func DomainToUnicode(domain string) (string, error) {
+	labels := splitDomain(domain)
+	for i, label := range labels {
+		label = strings.ToLower(label)
+		if strings.HasPrefix(label, idnaAcePrefix) {
+			decoded, err := punycodeDecode(label[len(idnaAcePrefix):])
+			if err != nil {
+				return "", err
+			}
+			label = decoded
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, "."), nil
+}
+
+/**
+ * labels of a domain; the ideographic full stops are label separators (RFC 3490 3.1)
+ */
+func splitDomain(domain string) []string {
+	var labels []string
+	start := 0
+	for i, c := range domain {
+		switch (c) {
+			case '.', '。', '．', '｡':
+				labels = append(labels, domain[start:i])
+				start = i + utf8.RuneLen(c)
+		}
+	}
+	return append(labels, domain[start:])
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func punycodeAdapt(delta int, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+/**
+ * threshold of the digit at position k
+ */
+func punycodeThreshold(k int, bias int) int {
+	switch {
+		case k <= bias:
+			return punycodeTMin
+		case k >= bias+punycodeTMax:
+			return punycodeTMax
+	}
+	return k - bias
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punycodeDigitValue(c byte) (int, bool) {
+	switch {
+		case c >= '0' && c <= '9':
+			return int(c-'0') + 26, true
+		case c >= 'a' && c <= 'z':
+			return int(c - 'a'), true
+		case c >= 'A' && c <= 'Z':
+			return int(c - 'A'), true
+	}
+	return 0, false
+}
+
+/**
+ * RFC 3492 6.3
+ */
+func punycodeEncode(s string) (string, error) {
+	input := []rune(s)
+	var output strings.Builder
+	for _, c := range input {
+		if c < utf8.RuneSelf {
+			output.WriteRune(c)
+		}
+	}
+	b := output.Len()
+	h := b
+	if b > 0 {
+		output.WriteByte('-')
+	}
+
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for h < len(input) {
+		m := punycodeMaxInt
+		for _, c := range input {
+			if int(c) >= n && int(c) < m {
+				m = int(c)
+			}
+		}
+		if (m-n) > (punycodeMaxInt-delta)/(h+1) {
+			return "", errPunycode
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+
+		for _, c := range input {
+			if int(c) < n {
+				delta++
+				if delta == punycodeMaxInt {
+					return "", errPunycode
+				}
+			}
+			if int(c) != n {
+				continue
+			}
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+				output.WriteByte(punycodeDigit(t + (q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			output.WriteByte(punycodeDigit(q))
+			bias = punycodeAdapt(delta, h+1, h == b)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return output.String(), nil
+}
+
+/**
+ * RFC 3492 6.2
+ */
+func punycodeDecode(s string) (string, error) {
+	var output []rune
+	pos := 0
+	if b := strings.LastIndexByte(s, '-'); b >= 0 {
+		for i := 0; i < b; i++ {
+			if s[i] >= utf8.RuneSelf {
+				return "", errPunycode
+			}
+			output = append(output, rune(s[i]))
+		}
+		pos = b + 1
+	}
+
+	n, i, bias := punycodeInitialN, 0, punycodeInitialBias
+	for pos < len(s) {
+		oldi, w := i, 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(s) {
+				return "", errPunycode
+			}
+			digit, ok := punycodeDigitValue(s[pos])
+			pos++
+			if !ok || digit > (punycodeMaxInt-i)/w {
+				return "", errPunycode
+			}
+			i += digit * w
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			if w > punycodeMaxInt/(punycodeBase-t) {
+				return "", errPunycode
+			}
+			w *= punycodeBase - t
+		}
+		bias = punycodeAdapt(i-oldi, len(output)+1, oldi == 0)
+		if i/(len(output)+1) > punycodeMaxInt-n {
+			return "", errPunycode
+		}
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > utf8.MaxRune || (n >= surrogateMin && n <= surrogateMax) {
+			// not a valid code point: string() would replace it with U+FFFD
+			return "", errPunycode
+		}
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+	}
+	return string(output), nil
+}
@@ -0,0 +1,77 @@
+package vcard
+
+import (
+	"testing"
+)
+
+/**
+ * samples of RFC 3492 7.1
+ */
+var punycodeTests = []struct {
+	name string
+	decoded string
+	encoded string
+}{
+	{"arabic", "ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+	{"chinese simplified", "他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+	{"chinese traditional", "他們爲什麽不說中文", "ihqwctvzc91f659drss3x8bo0yb"},
+	{"czech", "Pročprostěnemluvíčesky", "Proprostnemluvesky-uyb24dma41a"},
+	{"hebrew", "למההםפשוטלאמדבריםעברית", "4dbcagdahymbxekheh6e0a7fei0b"},
+	{"japanese", "なぜみんな日本語を話してくれないのか", "n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+	{"mixed", "3年B組金八先生", "3B-ww4c5e180e575a65lsy2b"},
+	{"ascii", "-> $1.00 <-", "-> $1.00 <--"},
+	{"german", "bücher", "bcher-kva"},
+}
+
+func TestPunycode(t *testing.T) {
+	for _, tt := range punycodeTests {
+		if encoded, err := punycodeEncode(tt.decoded); err != nil || encoded != tt.encoded {
+			t.Errorf("%s: punycodeEncode = %q, %v, want %q", tt.name, encoded, err, tt.encoded)
+		}
+		if decoded, err := punycodeDecode(tt.encoded); err != nil || decoded != tt.decoded {
+			t.Errorf("%s: punycodeDecode = %q, %v, want %q", tt.name, decoded, err, tt.decoded)
+		}
+	}
+}
+
+func TestPunycodeDecodeInvalid(t *testing.T) {
+	for _, s := range []string{
+		"bcher-kv",          // truncated digits
+		"bcher-k@a",         // not a base 36 digit
+		"bücher-kva",        // non ascii basic code point
+		"zzzzzzzzzzzzzzzzz", // delta overflow
+		"99999999999a",      // code point overflow
+		"a-rc4g",            // surrogate U+D800
+	} {
+		if decoded, err := punycodeDecode(s); err == nil {
+			t.Errorf("punycodeDecode(%q) = %q, want an error", s, decoded)
+		}
+	}
+}
+
+func TestDomainConversion(t *testing.T) {
+	tests := []struct {
+		unicode string
+		ascii string
+	}{
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"example.com", "example.com"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+	}
+	for _, tt := range tests {
+		if ascii, err := DomainToASCII(tt.unicode); err != nil || ascii != tt.ascii {
+			t.Errorf("DomainToASCII(%q) = %q, %v, want %q", tt.unicode, ascii, err, tt.ascii)
+		}
+		if unicode, err := DomainToUnicode(tt.ascii); err != nil || unicode != tt.unicode {
+			t.Errorf("DomainToUnicode(%q) = %q, %v, want %q", tt.ascii, unicode, err, tt.unicode)
+		}
+	}
+
+	// upper case and ideographic full stop
+	if ascii, _ := DomainToASCII("Bücher。Example"); ascii != "xn--bcher-kva.example" {
+		t.Errorf("DomainToASCII with an ideographic full stop = %q", ascii)
+	}
+	if _, err := DomainToUnicode("xn--a-rc4g.example"); err == nil {
+		t.Errorf("DomainToUnicode accepts a surrogate")
+	}
+}
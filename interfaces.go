@@ -11,12 +11,33 @@ type IVCard interface {
 	AddProperty(p IProperty)
 
 	GetProperties() []IProperty
+
+	// the name may have a group prefix (item1.EMAIL) to select the properties of a group
 	GetProperty(name string) []IProperty
 	DeleteProperty(name string)
 
+	// properties of a group (item1.EMAIL, item1.X-ABLABEL), in the order of the card
+	GetPropertiesInGroup(group string) []IProperty
+
+	// groups used by the properties, in the order of the card
+	GetGroups() []string
+
 	// build
 	Build() string
 
+	// return the vcard version (2.1, 3.0, 4.0)
+	GetVersion() string
+
+	// strict mode: the properties created by CreateProperty reject the values that don't match their value type
+	SetStrict(v bool)
+	GetStrict() bool
+
+	// the inline PHOTO and LOGO images are reduced with these options when the card is built; nil keeps the images
+	SetThumbnailOptions(o *ThumbnailOptions)
+	GetThumbnailOptions() *ThumbnailOptions
+
+	// errors of the images that could not be reduced by the last Build (their properties are not written)
+	GetThumbnailErrors() []error
 }
 
 /**
@@ -33,6 +54,13 @@ type IVCard interface {
 	 */
 	GetName() string
 
+	/**
+	 * group of the property: item1.EMAIL is the EMAIL property of the item1 group
+	 */
+	SetGroup(g string)
+
+	GetGroup() string
+
 	/**
 	 * set cardinality
 	 */
@@ -48,6 +76,41 @@ type IVCard interface {
 	 */
 	 SetAllowMultipleValues(v bool)
 
+	/**
+	 * check if the property allows multiple values
+	 */
+	 GetAllowMultipleValues() bool
+
+	/**
+	 * set the value types accepted by the property (uri, text, date, ...); the first one is the default type
+	 */
+	SetAcceptedValueTypes(types []string)
+
+	GetAcceptedValueTypes() []string
+
+	/**
+	 * type of the values: the VALUE parameter or the default type of the property ("" if unknown)
+	 */
+	GetValueType() string
+
+	/**
+	 * in strict mode AddValue / SetValue reject the values that don't match the value type
+	 * and AddParameter the parameters that are not accepted (see VCardProperty.SetAcceptedParameters)
+	 */
+	SetStrict(v bool)
+
+	GetStrict() bool
+
+	/**
+	 * errors of the values rejected in strict mode
+	 */
+	GetValueErrors() []error
+
+	/**
+	 * errors of the parameters rejected in strict mode
+	 */
+	GetParameterErrors() []error
+
 	/**
 	 * add a value to the property
 	 * if property is single value => the old value will be rewritten
@@ -79,12 +142,22 @@ type IVCard interface {
 	/**
 	 * set parameters removing all existing
 	 */
-	SetParameters(param map[string]IParameter)
+	SetParameters(param []IParameter)
+
+	/**
+	 * return parameters list, in the order they were added
+	 */
+	GetParameters() []IParameter
+
+	/**
+	 * return a parameter by name (nil if missing)
+	 */
+	GetParameter(name string) IParameter
 
 	/**
-	 * return parameters list
+	 * remove a parameter by name
 	 */
-	GetParameters() map[string]IParameter
+	DeleteParameter(name string)
 }
 
 
@@ -100,6 +173,14 @@ type IVCard interface {
 	GetString() string
 }
 
+/**
+ * value written differently for each version (ex: the dates, 1985-04-12 in vcard 3.0 and 19850412 in vcard 4.0)
+ */
+type IVersionedData interface {
+	IData
+	GetVersionedString(version string) string
+}
+
 /**
  * property parameter interface
  */
@@ -123,7 +204,12 @@ type IParameter interface {
 	/**
 	 * validate parameter values
 	 */
-	Validate() (bool, error)
+	Validate() bool
+
+	/**
+	 * the reason the parameter is not valid; nil if it is valid
+	 */
+	ValidateError() error
 
 	IsEmpty() bool
 }
@@ -170,7 +256,9 @@ type IBuilder interface {
 
 	Build() string
 
-
+	/**
+	 * property creators, configured with the schema of the version; nil if the version does not define the property
+	 */
 	NewBeginProperty() *VCardProperty
 	NewEndProperty() *VCardProperty
 	NewVersionProperty() *VCardProperty
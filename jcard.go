@@ -0,0 +1,325 @@
+/**
+ * jCard: the JSON format for vcard (RFC 7095)
+ *
+ *   ["vcard", [
+ *     ["version", {}, "text", "4.0"],
+ *     ["fn", {}, "text", "John Doe"],
+ *     ["n", {}, "text", ["Doe", "John", "", "", ""]],
+ *     ["tel", {"type": ["work", "voice"]}, "uri", "tel:+1-555-555-5555"],
+ *     ["email", {"group": "item1"}, "text", "john@example.com"]
+ *   ]]
+ */
+package vcard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+ * encode a card as jCard
+ * the card is written in its own version (the "version" property is not changed), use Convert to get a vcard 4.0 first
+ */
+func MarshalJCard(card IVCard) ([]byte, error) {
+	return json.Marshal(jcardArray(card))
+}
+
+func jcardArray(card IVCard) []interface{} {
+	properties := []interface{}{
+		[]interface{}{"version", map[string]interface{}{}, "text", card.GetVersion()},
+	}
+
+	for _, p := range card.GetProperties() {
+		switch p.GetName() {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+
+		params := map[string]interface{}{}
+		valueType := ""
+		for _, param := range p.GetParameters() {
+			name := param.GetName()
+			if name == "VALUE" {
+				if len(param.GetValue()) > 0 {
+					valueType = strings.ToLower(param.GetValue()[0])
+				}
+				continue
+			}
+			if len(param.GetValue()) == 1 {
+				params[strings.ToLower(name)] = param.GetValue()[0]
+			} else {
+				params[strings.ToLower(name)] = param.GetValue()
+			}
+		}
+		if valueType == "" {
+			valueType = valueTypeOf(p, card.GetVersion())
+		}
+		if p.GetGroup() != "" {
+			// RFC 7095 3.3.1.2: the group is a parameter
+			params["group"] = p.GetGroup()
+		}
+
+		entry := []interface{}{strings.ToLower(p.GetName()), params, valueType}
+		for _, v := range p.GetValue() {
+			entry = append(entry, jcardValue(v))
+		}
+		properties = append(properties, entry)
+	}
+
+	return []interface{}{"vcard", properties}
+}
+
+/**
+ * structured values are written as arrays of components, a component with more values as an array
+ */
+func jcardValue(d IData) interface{} {
+	switch v := d.(type) {
+		case *NameValue:
+			return []interface{}{
+				jcardComponent(v.FamilyName),
+				jcardComponent(v.GivenName),
+				jcardComponent(v.MiddleName),
+				jcardComponent(v.HonorificPrefixes),
+				jcardComponent(v.HonorificSuffixes),
+			}
+		case *AddressValue:
+			var adr []interface{}
+			for _, c := range v.Components() {
+				adr = append(adr, jcardComponent(c))
+			}
+			return adr
+		case *OrganizationValue:
+			if len(v.Departments) == 0 {
+				return v.Company
+			}
+			org := []interface{}{v.Company}
+			for _, d := range v.Departments {
+				org = append(org, d)
+			}
+			return org
+		case *GenderValue:
+			if v.Identity == "" {
+				return v.Sex
+			}
+			return []interface{}{v.Sex, v.Identity}
+		case *StructuredValue:
+			components := []interface{}{}
+			for _, c := range v.Components {
+				components = append(components, jcardComponent(c))
+			}
+			return components
+		case *GeoValue, *MediaValue:
+			// uris, not escaped
+			return d.GetString()
+		case dateTimeData:
+			// RFC 7095 3.5.3: extended format
+			return v.extendedString()
+	}
+	return d.GetValue()
+}
+
+func jcardComponent(values []string) interface{} {
+	switch len(values) {
+		case 0:
+			return ""
+		case 1:
+			return values[0]
+	}
+	return values
+}
+
+/**
+ * decode a jCard; the "version" property selects the type of the returned card (vcard 4.0 if missing)
+ */
+func UnmarshalJCard(data []byte) (IVCard, error) {
+	var raw []interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return jcardCard(raw)
+}
+
+func jcardCard(raw []interface{}) (IVCard, error) {
+	if len(raw) != 2 || raw[0] != "vcard" {
+		return nil, errors.New("vcard: jcard must be an array starting with \"vcard\"")
+	}
+	properties, ok := raw[1].([]interface{})
+	if !ok {
+		return nil, errors.New("vcard: jcard properties must be an array")
+	}
+
+	version := "4.0"
+	for _, rp := range properties {
+		if entry, ok := rp.([]interface{}); ok && len(entry) > 3 && strings.EqualFold(jsonString(entry[0]), "version") {
+			version = jsonString(entry[3])
+		}
+	}
+	vc, err := NewVCard(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rp := range properties {
+		entry, ok := rp.([]interface{})
+		if !ok || len(entry) < 4 {
+			return nil, fmt.Errorf("vcard: jcard property %d must be an array with name, parameters, type and value", i)
+		}
+		rawName, ok := entry[0].(string)
+		if !ok || rawName == "" {
+			return nil, fmt.Errorf("vcard: jcard property %d: the name must be a non empty string", i)
+		}
+		name := strings.ToUpper(rawName)
+		switch name {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+
+		p := vc.CreateProperty(name)
+		if p == nil {
+			return nil, fmt.Errorf("vcard: jcard property %d: invalid name %q", i, rawName)
+		}
+
+		params, ok := entry[1].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("vcard: jcard property %s: parameters must be an object", name)
+		}
+		// json objects have no order: the parameters are added sorted by name
+		var pnames []string
+		for pname := range params {
+			pnames = append(pnames, pname)
+		}
+		sort.Strings(pnames)
+		for _, pname := range pnames {
+			if strings.EqualFold(pname, "group") {
+				p.SetGroup(jsonString(params[pname]))
+				continue
+			}
+			vc.AddPropertyParameter(p, pname, jsonStrings(params[pname]))
+		}
+
+		valueType := strings.ToLower(jsonString(entry[2]))
+		if valueType != defaultValueType(name, version) && valueType != "unknown" {
+			vc.AddPropertyParameter(p, "VALUE", []string{valueType})
+		}
+
+		var values []IData
+		for _, rv := range entry[3:] {
+			values = append(values, jcardData(name, valueType, rv))
+		}
+		p.SetValue(values)
+
+		vc.AddProperty(p)
+	}
+
+	return vc, nil
+}
+
+/**
+ * create the typed value of a property from a jcard value
+ */
+func jcardData(name string, valueType string, rv interface{}) IData {
+	components, structured := rv.([]interface{})
+	if !structured {
+		components = []interface{}{rv}
+	}
+
+	switch name {
+		case "N":
+			n := NewName()
+			var c [5][]string
+			for i := 0; i < len(components) && i < 5; i++ {
+				c[i] = jsonStrings(components[i])
+			}
+			for _, s := range c[0] {
+				n.AddFamilyName(s)
+			}
+			for _, s := range c[1] {
+				n.AddGivenName(s)
+			}
+			for _, s := range c[2] {
+				n.AddMiddleName(s)
+			}
+			for _, s := range c[3] {
+				n.AddHonorificPrefix(s)
+			}
+			for _, s := range c[4] {
+				n.AddHonorificSuffix(s)
+			}
+			return n
+		case "ADR":
+			var c [][]string
+			for _, component := range components {
+				c = append(c, jsonStrings(component))
+			}
+			a := NewAddress()
+			a.SetComponents(c)
+			return a
+		case "ORG":
+			if !structured {
+				return NewOrganization(jsonString(rv), nil)
+			}
+			if len(components) == 0 {
+				return NewOrganization("", nil)
+			}
+			var departments []string
+			for _, d := range components[1:] {
+				departments = append(departments, jsonString(d))
+			}
+			return NewOrganization(jsonString(components[0]), departments)
+		case "GENDER":
+			if !structured {
+				return NewGender(jsonString(rv), "")
+			}
+			g := NewGender("", "")
+			if len(components) > 0 {
+				g.Sex = jsonString(components[0])
+			}
+			if len(components) > 1 {
+				g.Identity = jsonString(components[1])
+			}
+			return g
+	}
+
+	if structured {
+		// structured value of a property without a typed value (ex: CLIENTPIDMAP)
+		var parts [][]string
+		for _, c := range components {
+			parts = append(parts, jsonStrings(c))
+		}
+		return NewStructured(parts)
+	}
+	return newTypedValue(name, valueType, jsonString(rv))
+}
+
+/**
+ * string form of a json scalar (numbers and booleans are formatted)
+ */
+func jsonString(v interface{}) string {
+	switch s := v.(type) {
+		case string:
+			return s
+		case nil:
+			return ""
+		case []interface{}:
+			return strings.Join(jsonStrings(s), ",")
+	}
+	return fmt.Sprint(v)
+}
+
+/**
+ * values of a json scalar or array
+ */
+func jsonStrings(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return []string{jsonString(v)}
+	}
+	var result []string
+	for _, item := range list {
+		result = append(result, jsonString(item))
+	}
+	return result
+}
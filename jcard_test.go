@@ -0,0 +1,40 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestUnmarshalJCardEmptyStructuredValues(t *testing.T) {
+	for _, name := range []string{"n", "adr", "org", "gender", "clientpidmap"} {
+		data := `["vcard",[["version",{},"text","4.0"],["fn",{},"text","John"],["` + name + `",{},"text",[]]]]`
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic on an empty array: %v", name, r)
+				}
+			}()
+			if _, err := UnmarshalJCard([]byte(data)); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
+}
+
+func TestUnmarshalJCardInvalidName(t *testing.T) {
+	for _, data := range []string{
+		`["vcard",[["",{},"text","x"]]]`,
+		`["vcard",[[1,{},"text","x"]]]`,
+		`["vcard",[[null,{},"text","x"]]]`,
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic: %v", data, r)
+				}
+			}()
+			if _, err := UnmarshalJCard([]byte(data)); err == nil {
+				t.Errorf("%s: no error", data)
+			}
+		}()
+	}
+}
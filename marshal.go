@@ -0,0 +1,805 @@
+/**
+ * conversion between Go structs and cards, driven by the "vcard" struct tags
+ *
+ *	type Person struct {
+ *		Version string `vcard:"version"`           // card version, 4.0 if empty
+ *		Name string `vcard:"fn"`
+ *		Family string `vcard:"n.family"`           // component of a structured property
+ *		Given string `vcard:"n.given"`
+ *		WorkEmail string `vcard:"email,type=work"` // TYPE parameter
+ *		Emails []string `vcard:"email"`           // one property per element, without the work email (Unmarshal)
+ *		Birthday time.Time `vcard:"bday"`
+ *		Home Address `vcard:"adr,type=home"`      // nested struct, its fields are tagged with the component names
+ *		EmployeeId int `vcard:"x-employee-id"`
+ *	}
+ *
+ * components: N (family, given, additional, prefix, suffix), ADR (pobox, ext, street, locality, region, code, country),
+ * ORG (name, units), GENDER (sex, identity) and the components of the structured properties of the schemas
+ * fields without tag, with the "-" tag and with a zero value (Marshal) are skipped
+ */
+package vcard
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	dataType = reflect.TypeOf((*IData)(nil)).Elem()
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+/**
+ * parsed "vcard" tag: name[.component][,type=value...]
+ */
+type fieldTag struct {
+	name string
+	component string
+	types []string
+}
+
+func parseFieldTag(tag string) (fieldTag, error) {
+	parts := strings.Split(tag, ",")
+	t := fieldTag{
+		name: strings.ToUpper(strings.TrimSpace(parts[0])),
+	}
+	if i := strings.IndexByte(t.name, '.'); i >= 0 {
+		t.name, t.component = t.name[:i], strings.ToLower(t.name[i+1:])
+	}
+	if t.name == "" {
+		return t, fmt.Errorf("vcard: missing property name in tag %q", tag)
+	}
+	for _, option := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(option), "=", 2)
+		if len(kv) != 2 || strings.ToLower(kv[0]) != "type" || kv[1] == "" {
+			return t, fmt.Errorf("vcard: unknown option %q in tag %q", option, tag)
+		}
+		t.types = append(t.types, strings.ToLower(kv[1]))
+	}
+	return t, nil
+}
+
+/**
+ * key of the property built from the component fields (ex: "N", "ADR;home")
+ */
+func (t fieldTag) key() string {
+	return t.name + ";" + strings.Join(t.types, ",")
+}
+
+/**
+ * exported fields with a "vcard" tag; untagged embedded structs are walked
+ */
+func taggedFields(v reflect.Value, f func(tag fieldTag, fv reflect.Value) error) error {
+	st := v.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		tag, ok := sf.Tag.Lookup("vcard")
+		if !ok && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := taggedFields(v.Field(i), f); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok || tag == "-" || sf.PkgPath != "" {
+			continue
+		}
+		t, err := parseFieldTag(tag)
+		if err != nil {
+			return err
+		}
+		if err := f(t, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * create a card from a struct (or a pointer to a struct)
+ */
+func Marshal(v interface{}) (IVCard, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("vcard: Marshal of non struct type %T", v)
+	}
+
+	version := "4.0"
+	taggedFields(rv, func(t fieldTag, fv reflect.Value) error {
+		if t.name == "VERSION" && fv.Kind() == reflect.String && fv.String() != "" {
+			version = fv.String()
+		}
+		return nil
+	})
+	card, err := NewVCard(version)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &marshaler{
+		card: card,
+		version: version,
+		structured: map[string]IProperty{},
+	}
+	if err := taggedFields(rv, m.marshalField); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+type marshaler struct {
+	card IVCard
+	version string
+
+	// properties built from component fields, by tag key
+	structured map[string]IProperty
+}
+
+func (m *marshaler) marshalField(t fieldTag, fv reflect.Value) error {
+	if t.name == "VERSION" {
+		return nil
+	}
+
+	if t.component != "" {
+		values, err := scalarStrings(t, fv)
+		if err != nil || len(values) == 0 {
+			return err
+		}
+		p, ok := m.structured[t.key()]
+		if !ok {
+			d, err := newComponentValue(t.name)
+			if err != nil {
+				return err
+			}
+			p = m.addProperty(t, []IData{d})
+			m.structured[t.key()] = p
+		}
+		return setComponent(p.GetFirstValue(), t.name, t.component, values)
+	}
+
+	if fv.Type().Implements(dataType) {
+		if !fv.IsNil() {
+			m.addProperty(t, []IData{fv.Interface().(IData)})
+		}
+		return nil
+	}
+
+	switch {
+		case fv.Kind() == reflect.Ptr:
+			if fv.IsNil() {
+				return nil
+			}
+			return m.marshalField(t, fv.Elem())
+		case fv.Type() == timeType:
+			if tm := fv.Interface().(time.Time); !tm.IsZero() {
+				m.addTimeProperty(t, []IData{m.timeValue(t.name, tm)})
+			}
+			return nil
+		case isNestedStruct(fv.Type()):
+			d, err := m.componentStruct(t.name, fv)
+			if err != nil || d == nil {
+				return err
+			}
+			m.addProperty(t, []IData{d})
+			return nil
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+			return m.marshalSlice(t, fv)
+	}
+
+	values, err := scalarStrings(t, fv)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+	m.addProperty(t, []IData{m.textValue(t.name, values[0])})
+	return nil
+}
+
+/**
+ * a property for each element; the elements of a property with multiple values (CATEGORIES, NICKNAME) are values of one property
+ */
+func (m *marshaler) marshalSlice(t fieldTag, fv reflect.Value) error {
+	var values []IData
+	for i := 0; i < fv.Len(); i++ {
+		item := fv.Index(i)
+		if item.Kind() == reflect.Ptr && !item.Type().Implements(dataType) {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+
+		switch {
+			case item.Type().Implements(dataType):
+				if !item.IsNil() {
+					values = append(values, item.Interface().(IData))
+				}
+			case item.Type() == timeType:
+				if tm := item.Interface().(time.Time); !tm.IsZero() {
+					values = append(values, m.timeValue(t.name, tm))
+				}
+			case isNestedStruct(item.Type()):
+				d, err := m.componentStruct(t.name, item)
+				if err != nil {
+					return err
+				}
+				if d != nil {
+					values = append(values, d)
+				}
+			default:
+				s, err := formatScalar(t, item)
+				if err != nil {
+					return err
+				}
+				if s != "" {
+					values = append(values, m.textValue(t.name, s))
+				}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	if m.card.CreateProperty(t.name).GetAllowMultipleValues() {
+		m.addProperty(t, values)
+		return nil
+	}
+	for _, d := range values {
+		m.addProperty(t, []IData{d})
+	}
+	return nil
+}
+
+/**
+ * value of a structured property from the fields of a nested struct; nil if all the fields are empty
+ */
+func (m *marshaler) componentStruct(name string, sv reflect.Value) (IData, error) {
+	d, err := newComponentValue(name)
+	if err != nil {
+		return nil, err
+	}
+	empty := true
+	err = taggedFields(sv, func(t fieldTag, fv reflect.Value) error {
+		values, err := scalarStrings(t, fv)
+		if err != nil || len(values) == 0 {
+			return err
+		}
+		empty = false
+		// the tag of a nested field is the component name
+		return setComponent(d, name, strings.ToLower(t.name), values)
+	})
+	if err != nil || empty {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (m *marshaler) addProperty(t fieldTag, values []IData) IProperty {
+	p := m.card.CreateProperty(t.name)
+	p.SetValue(values)
+	if len(t.types) > 0 {
+		m.card.AddPropertyParameter(p, "TYPE", t.types)
+	}
+	m.card.AddProperty(p)
+	return p
+}
+
+/**
+ * value of a property from a string: typed for the properties with a dedicated value (GEO, PHOTO...)
+ */
+func (m *marshaler) textValue(name string, s string) IData {
+	return newTypedValue(name, defaultValueType(name, m.version), s)
+}
+
+/**
+ * REV is a timestamp; the other properties are dates or date-times
+ */
+func (m *marshaler) timeValue(name string, t time.Time) IData {
+	if defaultValueType(name, m.version) == "timestamp" {
+		return NewTimestamp(t.UTC())
+	}
+	return dateValueOf(t)
+}
+
+/**
+ * property with a time value; VALUE=date-time for a date-time in a date property
+ */
+func (m *marshaler) addTimeProperty(t fieldTag, values []IData) {
+	p := m.addProperty(t, values)
+	if _, ok := values[0].(*DateTimeValue); ok && defaultValueType(t.name, m.version) == "date" {
+		m.card.AddPropertyParameter(p, "VALUE", []string{"date-time"})
+	}
+}
+
+/**
+ * fill a struct (through a pointer) from a card; the fields without a matching property are left unchanged
+ * a tag with types matches the properties that have all the types; a scalar field receives the first value
+ * the properties matched by a tag with types of the struct are not matched by the tags without types, so that
+ * WorkEmail `vcard:"email,type=work"` and Emails `vcard:"email"` get back what Marshal wrote for them
+ */
+func Unmarshal(card IVCard, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("vcard: Unmarshal needs a non nil pointer to a struct, got %T", v)
+	}
+	u := &unmarshaler{
+		card: card,
+		claimed: map[IProperty]bool{},
+	}
+	err := taggedFields(rv.Elem(), func(t fieldTag, fv reflect.Value) error {
+		if len(t.types) > 0 {
+			for _, p := range u.properties(t) {
+				u.claimed[p] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return taggedFields(rv.Elem(), u.unmarshalField)
+}
+
+type unmarshaler struct {
+	card IVCard
+
+	// properties matched by the tags with types
+	claimed map[IProperty]bool
+}
+
+func (u *unmarshaler) unmarshalField(t fieldTag, fv reflect.Value) error {
+	if t.name == "VERSION" {
+		if fv.Kind() == reflect.String {
+			fv.SetString(u.card.GetVersion())
+		}
+		return nil
+	}
+
+	props := u.properties(t)
+	if len(props) == 0 {
+		return nil
+	}
+
+	if t.component != "" {
+		values, err := getComponent(props[0].GetFirstValue(), t.name, t.component)
+		if err != nil {
+			return err
+		}
+		return setStrings(t, fv, values)
+	}
+	return u.setField(t, fv, props)
+}
+
+func (u *unmarshaler) setField(t fieldTag, fv reflect.Value, props []IProperty) error {
+	first := props[0].GetFirstValue()
+
+	if fv.Type().Implements(dataType) {
+		if first != nil && reflect.TypeOf(first).AssignableTo(fv.Type()) {
+			fv.Set(reflect.ValueOf(first))
+		}
+		return nil
+	}
+
+	switch {
+		case fv.Kind() == reflect.Ptr:
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			return u.setField(t, fv.Elem(), props)
+		case fv.Type() == timeType:
+			if first == nil {
+				return nil
+			}
+			tm, ok := timeOf(first)
+			if !ok {
+				return fmt.Errorf("vcard: %s: invalid date %q", t.name, dataString(first))
+			}
+			fv.Set(reflect.ValueOf(tm))
+			return nil
+		case isNestedStruct(fv.Type()):
+			return componentFields(t.name, first, fv)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+			return u.setSlice(t, fv, props)
+	}
+
+	if first == nil {
+		return nil
+	}
+	return setScalar(t, fv, dataString(first))
+}
+
+/**
+ * an element for each value of the properties
+ */
+func (u *unmarshaler) setSlice(t fieldTag, fv reflect.Value, props []IProperty) error {
+	result := reflect.MakeSlice(fv.Type(), 0, len(props))
+	for _, p := range props {
+		for _, d := range p.GetValue() {
+			item := reflect.New(fv.Type().Elem()).Elem()
+			single := NewProperty(p.GetName())
+			single.SetValue([]IData{d})
+			if err := u.setField(t, item, []IProperty{single}); err != nil {
+				return err
+			}
+			result = reflect.Append(result, item)
+		}
+	}
+	fv.Set(result)
+	return nil
+}
+
+/**
+ * properties with the name and all the types of the tag; a tag without types does not match the claimed properties
+ */
+func (u *unmarshaler) properties(t fieldTag) []IProperty {
+	var result []IProperty
+	for _, p := range u.card.GetProperty(t.name) {
+		if len(t.types) == 0 && u.claimed[p] {
+			continue
+		}
+		var types []string
+		if param := p.GetParameter("TYPE"); param != nil {
+			for _, v := range param.GetValue() {
+				types = append(types, strings.ToLower(v))
+			}
+		}
+		matches := true
+		for _, v := range t.types {
+			if !containsString(types, v) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+/**
+ * fill the fields of a nested struct from the components of a value
+ */
+func componentFields(name string, d IData, sv reflect.Value) error {
+	if d == nil {
+		return nil
+	}
+	return taggedFields(sv, func(t fieldTag, fv reflect.Value) error {
+		values, err := getComponent(d, name, strings.ToLower(t.name))
+		if err != nil {
+			return err
+		}
+		return setStrings(t, fv, values)
+	})
+}
+
+/**
+ * struct mapped on the components of a structured property
+ */
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+/**
+ * empty value of a structured property
+ */
+func newComponentValue(name string) (IData, error) {
+	switch name {
+		case "N":
+			return NewName(), nil
+		case "ADR":
+			return NewAddress(), nil
+		case "ORG":
+			return NewOrganization("", nil), nil
+		case "GENDER":
+			return NewGender("", ""), nil
+	}
+	if components := propertyComponents(NewProperty(name)); len(components) > 0 {
+		return NewStructured(make([][]string, len(components))), nil
+	}
+	return nil, fmt.Errorf("vcard: %s is not a structured property", name)
+}
+
+func setComponent(d IData, name string, component string, values []string) error {
+	switch v := d.(type) {
+		case *NameValue:
+			switch component {
+				case "family", "surname":
+					v.FamilyName = values
+				case "given":
+					v.GivenName = values
+				case "additional", "middle":
+					v.MiddleName = values
+				case "prefix":
+					v.HonorificPrefixes = values
+				case "suffix":
+					v.HonorificSuffixes = values
+				default:
+					return unknownComponent(name, component)
+			}
+		case *AddressValue:
+			s := strings.Join(values, ",")
+			switch component {
+				case "pobox":
+					v.Pobox = s
+				case "ext":
+					v.Ext = s
+				case "street":
+					v.Street = s
+				case "locality":
+					v.Locality = s
+				case "region":
+					v.Region = s
+				case "code":
+					v.PostalCode = s
+				case "country":
+					v.Country = s
+				default:
+					return unknownComponent(name, component)
+			}
+		case *OrganizationValue:
+			switch component {
+				case "name":
+					v.Company = strings.Join(values, ",")
+				case "units":
+					v.Departments = values
+				default:
+					return unknownComponent(name, component)
+			}
+		case *GenderValue:
+			switch component {
+				case "sex":
+					v.Sex = strings.Join(values, ",")
+				case "identity":
+					v.Identity = strings.Join(values, ",")
+				default:
+					return unknownComponent(name, component)
+			}
+		case *StructuredValue:
+			i := componentIndex(name, component)
+			if i < 0 {
+				return unknownComponent(name, component)
+			}
+			for len(v.Components) <= i {
+				v.Components = append(v.Components, nil)
+			}
+			v.Components[i] = values
+		default:
+			return fmt.Errorf("vcard: %s value is not structured", name)
+	}
+	return nil
+}
+
+func getComponent(d IData, name string, component string) ([]string, error) {
+	var s string
+	switch v := d.(type) {
+		case *NameValue:
+			switch component {
+				case "family", "surname":
+					return v.FamilyName, nil
+				case "given":
+					return v.GivenName, nil
+				case "additional", "middle":
+					return v.MiddleName, nil
+				case "prefix":
+					return v.HonorificPrefixes, nil
+				case "suffix":
+					return v.HonorificSuffixes, nil
+			}
+			return nil, unknownComponent(name, component)
+		case *AddressValue:
+			switch component {
+				case "pobox":
+					s = v.Pobox
+				case "ext":
+					s = v.Ext
+				case "street":
+					s = v.Street
+				case "locality":
+					s = v.Locality
+				case "region":
+					s = v.Region
+				case "code":
+					s = v.PostalCode
+				case "country":
+					s = v.Country
+				default:
+					return nil, unknownComponent(name, component)
+			}
+			return splitComponent(s), nil
+		case *OrganizationValue:
+			switch component {
+				case "name":
+					s = v.Company
+				case "units":
+					return v.Departments, nil
+				default:
+					return nil, unknownComponent(name, component)
+			}
+		case *GenderValue:
+			switch component {
+				case "sex":
+					s = v.Sex
+				case "identity":
+					s = v.Identity
+				default:
+					return nil, unknownComponent(name, component)
+			}
+		case *StructuredValue:
+			i := componentIndex(name, component)
+			if i < 0 {
+				return nil, unknownComponent(name, component)
+			}
+			if i < len(v.Components) {
+				return v.Components[i], nil
+			}
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("vcard: %s value is not structured", name)
+	}
+	if s == "" {
+		return nil, nil
+	}
+	return []string{s}, nil
+}
+
+/**
+ * position of a component in the schema of a structured property; -1 if unknown
+ */
+func componentIndex(name string, component string) int {
+	for i, c := range propertyComponents(NewProperty(name)) {
+		if c == component {
+			return i
+		}
+	}
+	return -1
+}
+
+func unknownComponent(name string, component string) error {
+	return fmt.Errorf("vcard: unknown component %q of %s", component, name)
+}
+
+/**
+ * string of a value: unescaped text, or the rendered value of the typed values
+ */
+func dataString(d IData) string {
+	switch v := d.(type) {
+		case *TextValue:
+			return v.GetValue()
+		case *StructuredValue:
+			return v.GetValue()
+		case *EmailValue:
+			return v.GetValue()
+		case *TelValue:
+			return v.Text()
+	}
+	return d.GetString()
+}
+
+/**
+ * values of a scalar or slice field; nil for a zero value
+ */
+func scalarStrings(t fieldTag, fv reflect.Value) ([]string, error) {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Slice {
+		var result []string
+		for i := 0; i < fv.Len(); i++ {
+			s, err := formatScalar(t, fv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if s != "" {
+				result = append(result, s)
+			}
+		}
+		return result, nil
+	}
+	s, err := formatScalar(t, fv)
+	if err != nil || s == "" {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+/**
+ * string of a string, bool or number field; empty for a zero value
+ */
+func formatScalar(t fieldTag, fv reflect.Value) (string, error) {
+	switch fv.Kind() {
+		case reflect.String:
+			return fv.String(), nil
+		case reflect.Bool:
+			if !fv.Bool() {
+				return "", nil
+			}
+			return strconv.FormatBool(fv.Bool()), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if fv.Int() == 0 {
+				return "", nil
+			}
+			return strconv.FormatInt(fv.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if fv.Uint() == 0 {
+				return "", nil
+			}
+			return strconv.FormatUint(fv.Uint(), 10), nil
+		case reflect.Float32, reflect.Float64:
+			if fv.Float() == 0 {
+				return "", nil
+			}
+			return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("vcard: %s: unsupported field type %s", t.name, fv.Type())
+}
+
+/**
+ * set a scalar field to the first value, or a slice field to all the values
+ */
+func setStrings(t fieldTag, fv reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Slice {
+		result := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setScalar(t, result.Index(i), s); err != nil {
+				return err
+			}
+		}
+		fv.Set(result)
+		return nil
+	}
+	return setScalar(t, fv, values[0])
+}
+
+func setScalar(t fieldTag, fv reflect.Value, s string) error {
+	switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(s)
+			return nil
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("vcard: %s: invalid boolean %q", t.name, s)
+			}
+			fv.SetBool(b)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("vcard: %s: invalid integer %q", t.name, s)
+			}
+			fv.SetInt(n)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("vcard: %s: invalid integer %q", t.name, s)
+			}
+			fv.SetUint(n)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(s, fv.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("vcard: %s: invalid float %q", t.name, s)
+			}
+			fv.SetFloat(f)
+			return nil
+	}
+	return fmt.Errorf("vcard: %s: unsupported field type %s", t.name, fv.Type())
+}
@@ -0,0 +1,62 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+type marshalTestPerson struct {
+	Name string `vcard:"fn"`
+	Family string `vcard:"n.family"`
+	WorkEmail string `vcard:"email,type=work"`
+	Emails []string `vcard:"email"`
+	HomeStreet string `vcard:"adr.street,type=home"`
+}
+
+func TestMarshalRoundTripWithTypedAndUntypedTags(t *testing.T) {
+	in := marshalTestPerson{
+		Name: "John Smith",
+		Family: "Smith",
+		WorkEmail: "john@work.example",
+		Emails: []string{"john@home.example", "js@example.com"},
+		HomeStreet: "1 Main St",
+	}
+	card, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(card.GetProperty("EMAIL")); n != 3 {
+		t.Fatalf("%d EMAIL properties, want 3", n)
+	}
+
+	var out marshalTestPerson
+	if err := Unmarshal(card, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Unmarshal(Marshal(v)) = %+v, want %+v", out, in)
+	}
+
+	again, err := Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := NewBuilder(card).Build(), NewBuilder(again).Build(); a != b {
+		t.Errorf("Marshal after Unmarshal differs:\n%s\n%s", a, b)
+	}
+}
+
+func TestUnmarshalUntypedTagOnly(t *testing.T) {
+	card, _ := Marshal(marshalTestPerson{Name: "John", WorkEmail: "john@work.example", Emails: []string{"john@home.example"}})
+
+	// without a typed tag in the struct, the untyped tag matches all the properties
+	var out struct {
+		Emails []string `vcard:"email"`
+	}
+	if err := Unmarshal(card, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Emails) != 2 {
+		t.Errorf("Emails = %v, want the 2 addresses", out.Emails)
+	}
+}
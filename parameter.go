@@ -4,6 +4,8 @@
 package vcard
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -31,9 +33,40 @@ func (p *Parameter) AllowMultipleValues() bool {
 }
 
 
-func (p *Parameter) Validate() (result bool, err error) {
-	result = true
-	return
+/**
+ * check the syntax of the parameter (see ValidateError)
+ */
+func (p *Parameter) Validate() bool {
+	return p.ValidateError() == nil
+}
+
+/**
+ * check the syntax of the parameter:
+ *  param-name = 1*(ALPHA / DIGIT / "-")
+ *  at least one value; the values have no control characters (line breaks are allowed, see EscapeParameterValue)
+ * the number of values and the values specific to each parameter are checked by Validate (see validate.go)
+ */
+func (p *Parameter) ValidateError() error {
+	if p.name == "" {
+		return errors.New("parameter without name")
+	}
+	for _, c := range p.name {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return fmt.Errorf("invalid character %q in parameter name", c)
+		}
+	}
+
+	if len(p.value) == 0 {
+		return errors.New("parameter without value")
+	}
+	for _, v := range p.value {
+		for _, c := range v {
+			if c < 0x20 && c != '\t' && c != '\r' && c != '\n' || c == 0x7f {
+				return fmt.Errorf("invalid character %q in parameter value", c)
+			}
+		}
+	}
+	return nil
 }
 
 /**
@@ -0,0 +1,392 @@
+/**
+ * read a vcard from its text representation (vcard 2.1, RFC 2426, RFC 6350)
+ */
+package vcard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/**
+ * error returned when a vcard can not be parsed
+ * Line is the number (starting with 1) of the physical line where the content line starts
+ */
+type ParseError struct {
+	Line int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("vcard: line %d: %s", e.Line, e.Msg)
+}
+
+/**
+ * an unfolded content line
+ *   contentline  = [group "."] name *(";" param) ":" value CRLF
+ */
+type contentLine struct {
+	// physical line where the content line starts
+	line int
+
+	// property group (item1.EMAIL), case kept
+	group string
+
+	// property name, upper case
+	name string
+
+	params []*Parameter
+
+	// raw (escaped) value
+	value string
+}
+
+/**
+ * get the values of a parameter of the content line (nil if the parameter is missing)
+ */
+func (cl *contentLine) paramValues(name string) []string {
+	var result []string
+	for _, p := range cl.params {
+		if p.GetName() == name {
+			result = append(result, p.GetValue()...)
+		}
+	}
+	return result
+}
+
+/**
+ * parse an unfolded content line
+ */
+func parseContentLine(s string, line int) (*contentLine, error) {
+	cl := &contentLine{line: line}
+
+	i := strings.IndexAny(s, ";:")
+	if i < 0 {
+		return nil, &ParseError{Line: line, Msg: "missing ':' separator"}
+	}
+	cl.group, cl.name = splitGroup(strings.TrimSpace(s[:i]))
+	cl.name = strings.ToUpper(cl.name)
+	if cl.name == "" {
+		return nil, &ParseError{Line: line, Msg: "missing property name"}
+	}
+
+	for s[i] == ';' {
+		param, next, err := parseParameter(s, i+1, line)
+		if err != nil {
+			return nil, err
+		}
+		if param != nil {
+			cl.params = append(cl.params, param)
+		}
+		i = next
+		if i >= len(s) {
+			return nil, &ParseError{Line: line, Msg: "missing ':' separator"}
+		}
+	}
+
+	cl.value = s[i+1:]
+	return cl, nil
+}
+
+/**
+ * parse the parameter starting at position i
+ *   param        = param-name "=" param-value *("," param-value)
+ *   param-value  = ptext / quoted-string
+ * return the parameter and the position of the ';' or ':' char that follows it
+ */
+func parseParameter(s string, i int, line int) (*Parameter, int, error) {
+	end := strings.IndexAny(s[i:], "=;:")
+	if end < 0 {
+		return nil, len(s), nil
+	}
+	end += i
+
+	name := strings.TrimSpace(s[i:end])
+	if s[end] != '=' {
+		// vcard 2.1 parameter without name (TEL;HOME:..., NOTE;QUOTED-PRINTABLE:...)
+		if name == "" {
+			return nil, end, nil
+		}
+		var param *Parameter
+		switch (strings.ToUpper(name)) {
+			case "7BIT", "8BIT", "QUOTED-PRINTABLE", "BASE64", "B":
+				param = NewParameter("ENCODING")
+			case "INLINE", "URL", "CONTENT-ID", "CID":
+				param = NewParameter("VALUE")
+			default:
+				param = NewParameter("TYPE")
+		}
+		param.AddValue(name)
+		return param, end, nil
+	}
+
+	param := NewParameter(name)
+	i = end + 1
+	for {
+		var value string
+		if i < len(s) && s[i] == '"' {
+			closing := strings.IndexByte(s[i+1:], '"')
+			if closing < 0 {
+				return nil, len(s), &ParseError{Line: line, Msg: fmt.Sprintf("unterminated quoted value for parameter %s", param.GetName())}
+			}
+			value = s[i+1 : i+1+closing]
+			i += closing + 2
+		} else {
+			end = strings.IndexAny(s[i:], ",;:")
+			if end < 0 {
+				return nil, len(s), nil
+			}
+			value = s[i : i+end]
+			i += end
+		}
+		// the RFC 6868 encoding depends on the version, the values are unescaped by buildCard
+		param.AddValue(value)
+
+		if i >= len(s) || s[i] != ',' {
+			break
+		}
+		i++
+	}
+
+	return param, i, nil
+}
+
+/**
+ * parse a single vcard
+ * the text may be the output of Build() or any vcard 2.1 / RFC 2426 / RFC 6350 vcard; the VERSION property selects
+ * the returned type (*VCardV21, *VCardV3 or *VCardV4); if it contains more cards, only the first one is returned
+ */
+func Parse(s string) (IVCard, error) {
+	card, err := NewDecoder(strings.NewReader(s)).Decode()
+	if err == io.EOF {
+		return nil, &ParseError{Line: 1, Msg: "no vcard found"}
+	}
+	return card, err
+}
+
+/**
+ * create the vcard from the content lines found between BEGIN and END
+ */
+func buildCard(lines []*contentLine) (IVCard, error) {
+	var vc IVCard = NewVCardV3()
+	for _, cl := range lines {
+		if cl.name == "VERSION" {
+			var err error
+			version := strings.TrimSpace(cl.value)
+			if vc, err = NewVCard(version); err != nil {
+				return nil, &ParseError{Line: cl.line, Msg: fmt.Sprintf("unsupported version %q", version)}
+			}
+		}
+	}
+
+	for _, cl := range lines {
+		switch cl.name {
+			case "BEGIN", "END", "VERSION":
+				// these properties are generated by the builder
+				continue
+		}
+
+		decodeTransferEncoding(cl, vc.GetVersion())
+
+		p := vc.CreateProperty(cl.name)
+		p.SetGroup(cl.group)
+		for _, param := range cl.params {
+			vc.AddPropertyParameter(p, param.GetName(), unescapeParameterValues(param.GetValue(), vc.GetVersion()))
+		}
+		p.SetValue(decodeValue(p, cl))
+
+		vc.AddProperty(p)
+	}
+
+	return vc, nil
+}
+
+/**
+ * the RFC 6868 encoding (^^, ^n, ^') is used by vcard 3.0 and 4.0; the vcard 2.1 values are kept as they are
+ */
+func unescapeParameterValues(values []string, version string) []string {
+	if version == "2.1" {
+		return values
+	}
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = UnescapeParameterValue(v)
+	}
+	return result
+}
+
+/**
+ * decode QUOTED-PRINTABLE values and convert them from CHARSET to utf-8
+ * the values are kept decoded: for vcard 2.1 the builder encodes them back as utf-8,
+ * for the other versions the ENCODING=QUOTED-PRINTABLE and CHARSET parameters are removed
+ * a CHARSET that is not converted (see decodeCharset) is kept, the value keeps its bytes
+ */
+func decodeTransferEncoding(cl *contentLine, version string) {
+	quotedPrintable := false
+	for _, enc := range cl.paramValues("ENCODING") {
+		if strings.EqualFold(enc, "QUOTED-PRINTABLE") {
+			quotedPrintable = true
+		}
+	}
+	if quotedPrintable {
+		cl.value = decodeQuotedPrintable(cl.value)
+	}
+	converted := true
+	if charset := cl.paramValues("CHARSET"); len(charset) > 0 {
+		cl.value, converted = decodeCharset(cl.value, charset[0])
+	}
+
+	if version == "2.1" {
+		cl.value = v21ToEscaped(cl.value)
+		for _, param := range cl.params {
+			if param.GetName() == "CHARSET" && converted {
+				param.SetValue([]string{"UTF-8"})
+			}
+		}
+		return
+	}
+
+	var params []*Parameter
+	for _, param := range cl.params {
+		switch {
+			case param.GetName() == "CHARSET" && converted:
+			case param.GetName() == "ENCODING" && quotedPrintable:
+			default:
+				params = append(params, param)
+		}
+	}
+	cl.params = params
+}
+
+/**
+ * create the typed values of a property from the raw value
+ */
+func decodeValue(p IProperty, cl *contentLine) []IData {
+	switch p.GetName() {
+		case "N":
+			return []IData{parseName(cl.value)}
+		case "ADR":
+			return []IData{parseAddress(cl.value)}
+		case "ORG":
+			return []IData{parseOrganization(cl.value)}
+		case "GEO":
+			return []IData{parseGeo(cl.value)}
+		case "GENDER":
+			return []IData{parseGender(cl.value)}
+		case "PHOTO", "LOGO", "SOUND", "KEY":
+			return []IData{parsePhoto(cl)}
+	}
+
+	if components := propertyComponents(p); len(components) > 0 {
+		return []IData{NewStructured(structuredListComponents(cl.value, len(components)))}
+	}
+
+	if p.GetAllowMultipleValues() {
+		var values []IData
+		for _, v := range UnescapeTextList(cl.value) {
+			values = append(values, NewText(v))
+		}
+		return values
+	}
+
+	return []IData{newTypedValue(p.GetName(), p.GetValueType(), UnescapeValue(cl.value))}
+}
+
+/**
+ * split a structured value in exactly n unescaped components
+ */
+func structuredComponents(v string, n int) []string {
+	components := splitEscaped(v, ';')
+	result := make([]string, n)
+	for i := 0; i < n && i < len(components); i++ {
+		result[i] = UnescapeValue(components[i])
+	}
+	return result
+}
+
+/**
+ * split a structured value in exactly n components, each component being a list of unescaped values
+ */
+func structuredListComponents(v string, n int) [][]string {
+	components := UnescapeStructured(v)
+	result := make([][]string, n)
+	copy(result, components)
+	return result
+}
+
+func parseName(v string) *NameValue {
+	c := structuredListComponents(v, 5)
+	n := NewName()
+	for _, s := range c[0] {
+		n.AddFamilyName(s)
+	}
+	for _, s := range c[1] {
+		n.AddGivenName(s)
+	}
+	for _, s := range c[2] {
+		n.AddMiddleName(s)
+	}
+	for _, s := range c[3] {
+		n.AddHonorificPrefix(s)
+	}
+	for _, s := range c[4] {
+		n.AddHonorificSuffix(s)
+	}
+	return n
+}
+
+func parseAddress(v string) *AddressValue {
+	a := NewAddress()
+	a.SetComponents(structuredListComponents(v, 7))
+	return a
+}
+
+/**
+ * the components of ORG are single texts: a comma is a part of the text (ABC\, Inc.)
+ */
+func parseOrganization(v string) *OrganizationValue {
+	var departments []string
+	components := UnescapeStructured(v)
+	for _, c := range components[1:] {
+		departments = append(departments, strings.Join(c, ","))
+	}
+	return NewOrganization(strings.Join(components[0], ","), departments)
+}
+
+/**
+ * GEO may be a geo URI (geo:lat,lon[,alt][;params]), a vcard 3.0 "lat;lon" value or a vcard 2.1 "lat,lon" value
+ */
+func parseGeo(v string) *GeoValue {
+	g := NewGeo("", "", "")
+	g.SetValue(UnescapeValue(v))
+	return g
+}
+
+func parseGender(v string) *GenderValue {
+	c := structuredComponents(v, 2)
+	return NewGender(c[0], c[1])
+}
+
+/**
+ * binary values (PHOTO, LOGO, SOUND, KEY)
+ * inline values (ENCODING=b) are kept base64 encoded, their media type is the TYPE parameter or is sniffed from
+ * the data; any other value is autodetected
+ */
+func parsePhoto(cl *contentLine) *MediaValue {
+	for _, enc := range cl.paramValues("ENCODING") {
+		switch strings.ToLower(enc) {
+			case "b", "base64":
+				p := NewPhoto("")
+				p.IsB64Encoded = true
+				p.SetValue(strings.TrimSpace(cl.value))
+				if t := cl.paramValues("TYPE"); len(t) > 0 {
+					p.MediaType = mediaTypeFromType(cl.name, t[0])
+				} else if data, err := p.Bytes(); err == nil {
+					p.MediaType = SniffMediaType(data)
+				}
+				return p
+		}
+	}
+	return NewPhoto(UnescapeValue(strings.TrimSpace(cl.value)))
+}
@@ -0,0 +1,208 @@
+package vcard
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStructuredValuesRoundTrip(t *testing.T) {
+	for _, version := range []string{"3.0", "4.0"} {
+		for _, line := range []string{
+			"ADR:;;1 Main St,Apt 2;Town;;;",
+			"ADR:;;1 Main St\\;Back;Town;;12345;",
+			"ORG:ABC\\, Inc.;North\\; East;Sales",
+		} {
+			card, err := Parse("BEGIN:VCARD\r\nVERSION:" + version + "\r\nFN:John\r\nN:;John;;;\r\n" + line + "\r\nEND:VCARD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := NewBuilder(card).Build(); !strings.Contains(out, "\r\n"+line+"\r\n") {
+				t.Errorf("%s: %s is not kept:\n%s", version, line, out)
+			}
+		}
+	}
+}
+
+func TestAddressComponents(t *testing.T) {
+	card, _ := Parse("BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\r\nADR:;;1 Main St,Apt 2;Town;;;\r\nORG:ABC\\, Inc.;Sales\r\nEND:VCARD")
+
+	a := card.GetProperty("ADR")[0].GetFirstValue().(*AddressValue)
+	if want := []string{"1 Main St", "Apt 2"}; !reflect.DeepEqual(a.Components()[2], want) {
+		t.Errorf("street = %q, want %q", a.Components()[2], want)
+	}
+	o := card.GetProperty("ORG")[0].GetFirstValue().(*OrganizationValue)
+	if o.Company != "ABC, Inc." || !reflect.DeepEqual(o.Departments, []string{"Sales"}) {
+		t.Errorf("ORG = %q %q", o.Company, o.Departments)
+	}
+
+	data, err := MarshalJCard(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw []interface{}
+	json.Unmarshal(data, &raw)
+	for _, p := range raw[1].([]interface{}) {
+		entry := p.([]interface{})
+		if entry[0] != "adr" {
+			continue
+		}
+		street, ok := entry[3].([]interface{})[2].([]interface{})
+		if !ok || len(street) != 2 {
+			t.Errorf("jcard street is not a list: %s", data)
+		}
+	}
+
+	back, err := UnmarshalJCard(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := back.GetProperty("ADR")[0].GetFirstValue().GetString(); got != ";;1 Main St,Apt 2;Town;;;" {
+		t.Errorf("ADR from jcard = %q", got)
+	}
+}
+
+func TestParameterValueEncoding(t *testing.T) {
+	tests := []struct {
+		version string
+		line string
+		label string
+	}{
+		{"4.0", "ADR;LABEL=1 Main St^nTown ^'Centre^' ^^:;;1 Main St;Town;;;", "1 Main St\nTown \"Centre\" ^"},
+		{"3.0", "ADR;LABEL=1 Main St^nTown ^'Centre^' ^^:;;1 Main St;Town;;;", "1 Main St\nTown \"Centre\" ^"},
+		{"2.1", "ADR;X-LABEL=^n ^^:;;1 Main St;Town;;;", "^n ^^"},
+	}
+	for _, tt := range tests {
+		card, err := Parse("BEGIN:VCARD\r\nVERSION:" + tt.version + "\r\nFN:John\r\nN:;John;;;\r\n" + tt.line + "\r\nEND:VCARD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		adr := card.GetProperty("ADR")[0]
+		param := adr.GetParameter("LABEL")
+		if param == nil {
+			param = adr.GetParameter("X-LABEL")
+		}
+		if param == nil || param.GetValue()[0] != tt.label {
+			t.Errorf("%s: parameter value %v, want %q", tt.version, param, tt.label)
+			continue
+		}
+		if out := NewBuilder(card).Build(); !strings.Contains(out, "\r\n"+tt.line+"\r\n") {
+			t.Errorf("%s: %s is not kept:\n%s", tt.version, tt.line, out)
+		}
+	}
+
+	card := NewVCardV21()
+	tel := card.CreateProperty("TEL")
+	card.AddPropertyParameter(tel, "X-NOTE", []string{"a \"b\"\r\nc"})
+	tel.AddValue(NewText("+1 555 1234"))
+	card.AddProperty(tel)
+	if out := card.Build(); !strings.Contains(out, "TEL;X-NOTE=a b c:") {
+		t.Errorf("2.1 parameter value with quotes and line breaks:\n%s", out)
+	}
+}
+
+func TestCharsetParameter(t *testing.T) {
+	// ISO-8859-1 is converted to utf-8 and relabeled
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:2.1\r\nN:;John;;;\r\nNOTE;CHARSET=ISO-8859-1;ENCODING=QUOTED-PRINTABLE:Caf=E9\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := card.GetProperty("NOTE")[0]
+	if v := note.GetFirstValue().GetValue(); v != "Café" {
+		t.Errorf("ISO-8859-1 value %q", v)
+	}
+	if p := note.GetParameter("CHARSET"); p == nil || p.GetValue()[0] != "UTF-8" {
+		t.Errorf("ISO-8859-1 CHARSET %v", p)
+	}
+
+	// the other charsets keep their label with the bytes
+	for _, version := range []string{"2.1", "3.0"} {
+		card, err = Parse("BEGIN:VCARD\r\nVERSION:" + version + "\r\nN:;John;;;\r\nFN:John\r\nNOTE;CHARSET=SHIFT_JIS;ENCODING=QUOTED-PRINTABLE:=93=FA=96=7B\r\nEND:VCARD\r\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		note = card.GetProperty("NOTE")[0]
+		if v := note.GetFirstValue().GetValue(); v != "\x93\xfa\x96\x7b" {
+			t.Errorf("%s: SHIFT_JIS value %q", version, v)
+		}
+		if p := note.GetParameter("CHARSET"); p == nil || !strings.EqualFold(p.GetValue()[0], "SHIFT_JIS") {
+			t.Errorf("%s: SHIFT_JIS CHARSET %v", version, p)
+		}
+	}
+
+	if _, warnings, err := Convert(card, "4.0"); err != nil || len(warnings) == 0 {
+		t.Errorf("conversion of a SHIFT_JIS value to 4.0: %v %v", warnings, err)
+	}
+}
+
+func TestParseV3(t *testing.T) {
+	s := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:John Doe\r\n" +
+		"N:Doe;John;Q.,Quinlan;Dr.;Jr.\r\n" +
+		"ORG:Example\\, Inc.;Research;Lab\r\n" +
+		"ADR;TYPE=work,postal:;Suite 5;1 Main St;Town;;12345;USA\r\n" +
+		"NOTE:a long note that is folded on two lines\\, with an escaped comma\r\n" +
+		"  and a new line\\nhere\r\n" +
+		"EMAIL;TYPE=internet;X-LABEL=\"home; office: main\":john@example.com\r\n" +
+		"PHOTO;VALUE=uri:http://example.com/john.jpg\r\n" +
+		"END:VCARD\r\n"
+
+	card, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := card.(*VCardV3); !ok {
+		t.Fatalf("card %T", card)
+	}
+
+	n, ok := card.GetProperty("N")[0].GetFirstValue().(*NameValue)
+	if !ok {
+		t.Fatalf("N value %T", card.GetProperty("N")[0].GetFirstValue())
+	}
+	if !reflect.DeepEqual(n.FamilyName, []string{"Doe"}) || !reflect.DeepEqual(n.MiddleName, []string{"Q.", "Quinlan"}) || !reflect.DeepEqual(n.HonorificSuffixes, []string{"Jr."}) {
+		t.Errorf("N %+v", n)
+	}
+
+	org, ok := card.GetProperty("ORG")[0].GetFirstValue().(*OrganizationValue)
+	if !ok || org.Company != "Example, Inc." || !reflect.DeepEqual(org.Departments, []string{"Research", "Lab"}) {
+		t.Errorf("ORG %+v", card.GetProperty("ORG")[0].GetFirstValue())
+	}
+
+	adr, ok := card.GetProperty("ADR")[0].GetFirstValue().(*AddressValue)
+	if !ok || adr.Ext != "Suite 5" || adr.Street != "1 Main St" || adr.PostalCode != "12345" || adr.Country != "USA" {
+		t.Errorf("ADR %+v", card.GetProperty("ADR")[0].GetFirstValue())
+	}
+	if types := card.GetProperty("ADR")[0].GetParameter("TYPE").GetValue(); !reflect.DeepEqual(types, []string{"work", "postal"}) {
+		t.Errorf("ADR TYPE %v", types)
+	}
+
+	if note := card.GetProperty("NOTE")[0].GetFirstValue().GetValue(); note != "a long note that is folded on two lines, with an escaped comma and a new line\nhere" {
+		t.Errorf("NOTE %q", note)
+	}
+	if label := card.GetProperty("EMAIL")[0].GetParameter("X-LABEL").GetValue(); !reflect.DeepEqual(label, []string{"home; office: main"}) {
+		t.Errorf("quoted parameter value %v", label)
+	}
+	if photo, ok := card.GetProperty("PHOTO")[0].GetFirstValue().(*MediaValue); !ok || !photo.IsUrl {
+		t.Errorf("PHOTO %+v", card.GetProperty("PHOTO")[0].GetFirstValue())
+	}
+
+	// the output of Build is read back unchanged
+	out := card.Build()
+	again, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Build() != out {
+		t.Errorf("Parse(Build()) changes the card:\n%s\n%s", out, again.Build())
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := Parse("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:John\r\n  Doe\r\nno separator\r\nEND:VCARD\r\n")
+	perr, ok := err.(*ParseError)
+	if !ok || perr.Line != 5 {
+		t.Errorf("error %v, want a *ParseError at line 5", err)
+	}
+}
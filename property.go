@@ -1,6 +1,7 @@
 package vcard
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -12,11 +13,14 @@ type VCardProperty struct {
 	// property name - should be uppercase
 	name string
 
+	// group of the property (item1.EMAIL); the case is kept, groups are compared case insensitive
+	group string
+
 	// property values
 	values []IData
 
-	// property parameters
-	parameters map[string]IParameter
+	// property parameters, in the order they were added
+	parameters []IParameter
 
 	cardinality string
 
@@ -24,6 +28,17 @@ type VCardProperty struct {
 
 	acceptedValueTypes []string
 
+	// parameters accepted by the property (see PropertySchema.Parameters); nil: any parameter
+	acceptedParameters []string
+
+	// reject the values that don't match the value type
+	strict bool
+
+	// values rejected in strict mode
+	valueErrors []error
+
+	// parameters rejected in strict mode
+	parameterErrors []error
 }
 
 /**
@@ -35,17 +50,135 @@ func (p *VCardProperty) SetAcceptedValueTypes(acceptedTypes []string) {
 	p.acceptedValueTypes = acceptedTypes
 }
 
+func (p *VCardProperty) GetAcceptedValueTypes() []string {
+	return p.acceptedValueTypes
+}
+
+/**
+ * the VALUE parameter selects the type; without it the first accepted type is used
+ * vcard 2.1 VALUE=URL is an uri, the other 2.1 values (INLINE, CONTENT-ID) keep the default type
+ */
+func (p *VCardProperty) GetValueType() string {
+	if param := p.GetParameter("VALUE"); param != nil && len(param.GetValue()) > 0 {
+		switch t := strings.ToLower(param.GetValue()[0]); t {
+			case "url":
+				return "uri"
+			case "content-id", "cid":
+				// a reference to another part of the message
+				return ""
+			case "inline":
+			default:
+				return t
+		}
+	}
+	if len(p.acceptedValueTypes) > 0 {
+		return p.acceptedValueTypes[0]
+	}
+	return ""
+}
+
+/**
+ * set the accepted parameters; nil accepts any parameter, an empty list none. the X- parameters are always accepted
+ */
+func (p *VCardProperty) SetAcceptedParameters(names []string) {
+	if names != nil {
+		names = append([]string{}, names...)
+		for i, name := range names {
+			names[i] = strings.ToUpper(name)
+		}
+	}
+	p.acceptedParameters = names
+}
+
+func (p *VCardProperty) GetAcceptedParameters() []string {
+	return p.acceptedParameters
+}
+
+/**
+ * true if the parameter may be used with the property
+ */
+func (p *VCardProperty) AcceptsParameter(name string) bool {
+	name = strings.ToUpper(name)
+	return p.acceptedParameters == nil || strings.HasPrefix(name, "X-") || containsString(p.acceptedParameters, name)
+}
+
+func (p *VCardProperty) SetStrict(v bool) {
+	p.strict = v
+}
+
+func (p *VCardProperty) GetStrict() bool {
+	return p.strict
+}
+
+func (p *VCardProperty) GetValueErrors() []error {
+	return p.valueErrors
+}
+
+func (p *VCardProperty) GetParameterErrors() []error {
+	return p.parameterErrors
+}
+
+/**
+ * check a value against the value type of the property
+ */
+func (p *VCardProperty) checkValue(v IData) error {
+	valueType := p.GetValueType()
+	if valueType == "" {
+		return nil
+	}
+	if len(p.acceptedValueTypes) > 0 && !strings.HasPrefix(valueType, "x-") && !containsString(p.acceptedValueTypes, valueType) {
+		return fmt.Errorf("%s: value type %s not accepted", p.name, valueType)
+	}
+	if !v.Validate() {
+		return fmt.Errorf("%s: invalid %s value", p.name, strings.ToLower(v.GetType()))
+	}
+	// the extended iso 8601 forms of the older versions are accepted
+	if !validData(v, valueType, true) {
+		return fmt.Errorf("%s: value %q is not a valid %s", p.name, v.GetString(), valueType)
+	}
+	return nil
+}
+
+/**
+ * strict mode: return false (and keep the error) if the value doesn't match the value type
+ */
+func (p *VCardProperty) acceptValue(v IData) bool {
+	if !p.strict {
+		return true
+	}
+	if err := p.checkValue(v); err != nil {
+		p.valueErrors = append(p.valueErrors, err)
+		return false
+	}
+	return true
+}
+
 /**
-*  set tag name upper case
+*  set tag name upper case; a group prefix (item1.EMAIL) sets the group
 */
 func (p *VCardProperty) SetName(n string) {
-	p.name = strings.ToUpper(n);
+	group, name := splitGroup(n)
+	if group != "" {
+		p.group = group
+	}
+	p.name = strings.ToUpper(name);
 }
 
 func (p *VCardProperty) GetName() string {
 	return p.name
 }
 
+/**
+ * set the group of the property; empty for no group
+ */
+func (p *VCardProperty) SetGroup(g string) {
+	p.group = g
+}
+
+func (p *VCardProperty) GetGroup() string {
+	return p.group
+}
+
 func (p *VCardProperty) SetCardinality(v string) {
 	switch (v) {
 		case "1", "*1", "1*", "*":
@@ -70,8 +203,12 @@ func (p *VCardProperty) GetAllowMultipleValues() bool {
 
 /**
 * add a value to property
+* in strict mode a value that doesn't match the value type is not added (see GetValueErrors)
 */
 func (p *VCardProperty) AddValue(v IData) {
+	if !p.acceptValue(v) {
+		return
+	}
 	p.values = append(p.values, v)
 }
 
@@ -94,43 +231,92 @@ func (p *VCardProperty) GetFirstValue() IData {
 
 /**
  * set property list values, reseting existing ones
+ * in strict mode the values that don't match the value type are dropped (see GetValueErrors)
  */
 
 func (p *VCardProperty) SetValue(values []IData)  {
-	 p.values = values
+	if !p.strict {
+		p.values = values
+		return
+	}
+	p.values = nil
+	for _, v := range values {
+		p.AddValue(v)
+	}
 }
 
 /**
  * add a parameter to a property
+ * the values of a parameter that already exists are appended to it, keeping its position
+ * in strict mode a parameter that is not accepted is not added (see GetParameterErrors)
  */
 func (p *VCardProperty) AddParameter(param IParameter) {
-   existingParam, ok := p.parameters[param.GetName()];
-   if ok {
-	 for _, nv := range param.GetValue() {
-		 existingParam.AddValue(nv)
-	 }
-   } else {
-	   if p.parameters == nil {
-			p.parameters = map[string]IParameter{}
-	   }
-	   p.parameters[param.GetName()] = param
-   }
+	if p.strict && !p.AcceptsParameter(param.GetName()) {
+		p.parameterErrors = append(p.parameterErrors, fmt.Errorf("%s: parameter %s not accepted", p.name, param.GetName()))
+		return
+	}
+
+	existingParam := p.GetParameter(param.GetName())
+	if existingParam != nil {
+		for _, nv := range param.GetValue() {
+			existingParam.AddValue(nv)
+		}
+	} else {
+		p.parameters = append(p.parameters, param)
+	}
 }
 
 /**
  * set the parameters list, reseting any existing parameter
  */
-func (p *VCardProperty) SetParameters(paramList map[string]IParameter) {
-   p.parameters = paramList
+func (p *VCardProperty) SetParameters(paramList []IParameter) {
+	p.parameters = paramList
 }
 
 /**
- * get the property parameters list
+ * get the property parameters list, in the order they were added
  */
-func (p *VCardProperty) GetParameters() map[string]IParameter {
+func (p *VCardProperty) GetParameters() []IParameter {
 	return p.parameters
 }
 
+/**
+ * get a parameter by name; nil if the property doesn't have it
+ */
+func (p *VCardProperty) GetParameter(name string) IParameter {
+	name = strings.ToUpper(name)
+	for _, param := range p.parameters {
+		if param.GetName() == name {
+			return param
+		}
+	}
+	return nil
+}
+
+/**
+ * remove a parameter by name
+ */
+func (p *VCardProperty) DeleteParameter(name string) {
+	name = strings.ToUpper(name)
+	kept := p.parameters[:0]
+	for _, param := range p.parameters {
+		if param.GetName() != name {
+			kept = append(kept, param)
+		}
+	}
+	p.parameters = kept
+}
+
+/**
+ * split a property name in group and name: "item1.EMAIL" -> "item1", "EMAIL"
+ */
+func splitGroup(n string) (string, string) {
+	if i := strings.LastIndexByte(n, '.'); i >= 0 {
+		return n[:i], n[i+1:]
+	}
+	return "", n
+}
+
 /**
  *	create a generic property
  */
@@ -141,7 +327,7 @@ func (p *VCardProperty) GetParameters() map[string]IParameter {
 	}
 
 	p := &VCardProperty{}
-	p.SetName(strings.ToUpper(name))
+	p.SetName(name)
 
 	return p
 }
\ No newline at end of file
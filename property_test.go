@@ -0,0 +1,70 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPropertyValueTypes(t *testing.T) {
+	vc := NewVCardV4()
+	tests := map[string][]string{
+		"URL": {"uri"},
+		"BDAY": {"date-and-or-time", "text"},
+		"REV": {"timestamp"},
+		"TZ": {"text", "uri", "utc-offset"},
+	}
+	for name, want := range tests {
+		if got := vc.CreateProperty(name).GetAcceptedValueTypes(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s value types %v, want %v", name, got, want)
+		}
+	}
+
+	bday := vc.CreateProperty("BDAY")
+	if bday.GetValueType() != "date-and-or-time" {
+		t.Errorf("BDAY default type %q", bday.GetValueType())
+	}
+	vc.AddPropertyParameter(bday, "VALUE", []string{"text"})
+	if bday.GetValueType() != "text" {
+		t.Errorf("BDAY;VALUE=text type %q", bday.GetValueType())
+	}
+}
+
+func TestStrictValues(t *testing.T) {
+	vc := NewVCardV4()
+	vc.SetStrict(true)
+
+	url := vc.CreateProperty("URL")
+	url.AddValue(NewText("not an uri"))
+	url.AddValue(NewText("https://example.com/"))
+	if len(url.GetValue()) != 1 || len(url.GetValueErrors()) != 1 {
+		t.Errorf("strict URL: values %v, errors %v", url.GetValue(), url.GetValueErrors())
+	}
+
+	bday := vc.CreateProperty("BDAY")
+	bday.SetValue([]IData{NewText("circa 1800")})
+	if len(bday.GetValue()) != 0 || len(bday.GetValueErrors()) != 1 {
+		t.Errorf("strict BDAY: values %v, errors %v", bday.GetValue(), bday.GetValueErrors())
+	}
+
+	// the VALUE parameter selects the type the values are checked with
+	bday = vc.CreateProperty("BDAY")
+	vc.AddPropertyParameter(bday, "VALUE", []string{"text"})
+	bday.AddValue(NewText("circa 1800"))
+	if len(bday.GetValue()) != 1 || len(bday.GetValueErrors()) != 0 {
+		t.Errorf("strict BDAY;VALUE=text: values %v, errors %v", bday.GetValue(), bday.GetValueErrors())
+	}
+
+	rev := vc.CreateProperty("REV")
+	vc.AddPropertyParameter(rev, "VALUE", []string{"boolean"})
+	rev.AddValue(NewText("true"))
+	if len(rev.GetValue()) != 0 || len(rev.GetValueErrors()) != 1 {
+		t.Errorf("strict REV;VALUE=boolean: values %v, errors %v", rev.GetValue(), rev.GetValueErrors())
+	}
+
+	// without strict mode the values are kept
+	url = NewVCardV4().CreateProperty("URL")
+	url.AddValue(NewText("not an uri"))
+	if len(url.GetValue()) != 1 || len(url.GetValueErrors()) != 0 {
+		t.Errorf("URL: values %v, errors %v", url.GetValue(), url.GetValueErrors())
+	}
+}
@@ -0,0 +1,394 @@
+/**
+ * property and parameter schemas of each vcard version
+ * CreateProperty, AddPropertyParameter, Validate, Convert and the parsers read the rules from here
+ */
+package vcard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+ * the rules of a property in a vcard version
+ */
+type PropertySchema struct {
+	Name string
+
+	// "1" exactly one, "*1" at most one, "1*" at least one, "*" any number
+	Cardinality string
+
+	// the value is a comma separated list (NICKNAME, CATEGORIES)
+	MultipleValues bool
+
+	// accepted value types; the first one is the default type (used without VALUE parameter)
+	// nil: any type, text by default
+	ValueTypes []string
+
+	// accepted parameters; nil: any parameter of the version. the X- parameters are always accepted
+	Parameters []string
+
+	// accepted values of some parameters (ex: TYPE of TEL); other values are reported as warnings by Validate
+	ParameterValues map[string][]string
+
+	// names of the components of a structured value (";" separated), in order; nil if the value is not structured
+	// the names are the xCard element names
+	Components []string
+}
+
+/**
+ * true if the parameter may be used with the property
+ */
+func (s PropertySchema) AllowsParameter(name string) bool {
+	name = strings.ToUpper(name)
+	return s.Parameters == nil || strings.HasPrefix(name, "X-") || containsString(s.Parameters, name)
+}
+
+/**
+ * the rules of a parameter in a vcard version
+ */
+type ParameterSchema struct {
+	Name string
+
+	// the parameter may have more values (TYPE=work,voice)
+	MultipleValues bool
+
+	// accepted values (case insensitive); nil: any value
+	Values []string
+}
+
+var (
+	schemaMutex sync.RWMutex
+
+	propertySchemas = map[string]map[string]PropertySchema{
+		"2.1": schemaMap(v21PropertySchemas),
+		"3.0": schemaMap(v3PropertySchemas),
+		"4.0": schemaMap(v4PropertySchemas),
+	}
+
+	// properties defined by the specifications, in any version (the registered X- properties are not included)
+	standardProperties = map[string]bool{}
+)
+
+func init() {
+	for _, schemas := range propertySchemas {
+		for name := range schemas {
+			standardProperties[name] = true
+		}
+	}
+}
+
+func schemaMap(schemas []PropertySchema) map[string]PropertySchema {
+	m := map[string]PropertySchema{}
+	for _, s := range schemas {
+		m[s.Name] = s
+	}
+	return m
+}
+
+var parameterSchemas = map[string]map[string]ParameterSchema{
+	"2.1": {
+		"TYPE": {Name: "TYPE", MultipleValues: true},
+		"VALUE": {Name: "VALUE", Values: []string{"inline", "url", "content-id", "cid"}},
+		"ENCODING": {Name: "ENCODING", Values: []string{"7bit", "8bit", "quoted-printable", "base64"}},
+		"CHARSET": {Name: "CHARSET"},
+		"LANGUAGE": {Name: "LANGUAGE"},
+	},
+	"3.0": {
+		"TYPE": {Name: "TYPE", MultipleValues: true},
+		"VALUE": {Name: "VALUE", Values: []string{"binary", "boolean", "date", "date-time", "float", "integer", "text", "time", "uri", "utc-offset", "phone-number", "vcard"}},
+		"ENCODING": {Name: "ENCODING", Values: []string{"b"}},
+		"CHARSET": {Name: "CHARSET"},
+		"LANGUAGE": {Name: "LANGUAGE"},
+		"CONTEXT": {Name: "CONTEXT"},
+	},
+	"4.0": {
+		"LANGUAGE": {Name: "LANGUAGE"},
+		"VALUE": {Name: "VALUE", Values: []string{"text", "uri", "date", "time", "date-time", "date-and-or-time", "timestamp", "boolean", "integer", "float", "utc-offset", "language-tag"}},
+		"PREF": {Name: "PREF"},
+		"ALTID": {Name: "ALTID"},
+		"PID": {Name: "PID", MultipleValues: true},
+		"TYPE": {Name: "TYPE", MultipleValues: true},
+		"MEDIATYPE": {Name: "MEDIATYPE"},
+		"CALSCALE": {Name: "CALSCALE", Values: []string{"gregorian"}},
+		"SORT-AS": {Name: "SORT-AS", MultipleValues: true},
+		"GEO": {Name: "GEO"},
+		"TZ": {Name: "TZ"},
+		"LABEL": {Name: "LABEL"},
+	},
+}
+
+var (
+	nameComponents = []string{"surname", "given", "additional", "prefix", "suffix"}
+	addressComponents = []string{"pobox", "ext", "street", "locality", "region", "code", "country"}
+
+	v21TelTypes = []string{"pref", "work", "home", "voice", "fax", "msg", "cell", "pager", "bbs", "modem", "car", "isdn", "video"}
+	v21AdrTypes = []string{"dom", "intl", "postal", "parcel", "home", "work", "pref"}
+	v21EmailTypes = []string{"aol", "applelink", "attmail", "cis", "eworld", "internet", "ibmmail", "mcimail", "powershare", "prodigy", "tlx", "x400", "pref", "home", "work"}
+
+	v3TelTypes = []string{"home", "msg", "work", "pref", "voice", "fax", "cell", "video", "pager", "bbs", "modem", "car", "isdn", "pcs"}
+	v3AdrTypes = []string{"dom", "intl", "postal", "parcel", "home", "work", "pref"}
+	v3EmailTypes = []string{"internet", "x400", "pref", "home", "work"}
+
+	v4Types = []string{"work", "home"}
+	v4TelTypes = []string{"work", "home", "text", "voice", "fax", "cell", "video", "pager", "textphone"}
+	v4RelatedTypes = []string{"contact", "acquaintance", "friend", "met", "co-worker", "colleague", "co-resident", "neighbor", "child", "parent", "sibling", "spouse", "kin", "muse", "crush", "date", "sweetheart", "me", "agent", "emergency"}
+)
+
+/**
+ * vcard 2.1 (versit specification); the parameters are not restricted per property
+ */
+var v21PropertySchemas = []PropertySchema{
+	{Name: "BEGIN", Cardinality: "1", Parameters: []string{}},
+	{Name: "END", Cardinality: "1", Parameters: []string{}},
+	{Name: "VERSION", Cardinality: "1", Parameters: []string{}},
+	{Name: "N", Cardinality: "1", ValueTypes: []string{"text"}, Components: nameComponents},
+	{Name: "FN", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "PHOTO", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "BDAY", Cardinality: "*1", ValueTypes: []string{"date", "date-time"}},
+	{Name: "ADR", Cardinality: "*", ValueTypes: []string{"text"}, Components: addressComponents, ParameterValues: map[string][]string{"TYPE": v21AdrTypes}},
+	{Name: "LABEL", Cardinality: "*", ValueTypes: []string{"text"}, ParameterValues: map[string][]string{"TYPE": v21AdrTypes}},
+	{Name: "TEL", Cardinality: "*", ValueTypes: []string{"text"}, ParameterValues: map[string][]string{"TYPE": v21TelTypes}},
+	{Name: "EMAIL", Cardinality: "*", ValueTypes: []string{"text"}, ParameterValues: map[string][]string{"TYPE": v21EmailTypes}},
+	{Name: "MAILER", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "TZ", Cardinality: "*1", ValueTypes: []string{"utc-offset", "text"}},
+	{Name: "GEO", Cardinality: "*1", ValueTypes: []string{"float"}},
+	{Name: "TITLE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "ROLE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "LOGO", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "AGENT", Cardinality: "*", ValueTypes: []string{"text", "vcard", "uri"}},
+	{Name: "ORG", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "NOTE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "REV", Cardinality: "*1", ValueTypes: []string{"date-time", "date"}},
+	{Name: "SOUND", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "URL", Cardinality: "*", ValueTypes: []string{"uri"}},
+	{Name: "UID", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "KEY", Cardinality: "*", ValueTypes: []string{"binary", "text"}},
+}
+
+/**
+ * vcard 3.0 (RFC 2426, with IMPP from RFC 4770 and the calendar uris from RFC 2739);
+ * the parameters are not restricted per property
+ */
+var v3PropertySchemas = []PropertySchema{
+	{Name: "BEGIN", Cardinality: "1", Parameters: []string{}},
+	{Name: "END", Cardinality: "1", Parameters: []string{}},
+	{Name: "VERSION", Cardinality: "1", Parameters: []string{}},
+	{Name: "PROFILE", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "NAME", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "SOURCE", Cardinality: "*1", ValueTypes: []string{"uri"}},
+	{Name: "FN", Cardinality: "1", ValueTypes: []string{"text"}},
+	{Name: "N", Cardinality: "1", ValueTypes: []string{"text"}, Components: nameComponents},
+	{Name: "NICKNAME", Cardinality: "*", MultipleValues: true, ValueTypes: []string{"text"}},
+	{Name: "PHOTO", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "BDAY", Cardinality: "*1", ValueTypes: []string{"date", "date-time"}},
+	{Name: "ADR", Cardinality: "*", ValueTypes: []string{"text"}, Components: addressComponents, ParameterValues: map[string][]string{"TYPE": v3AdrTypes}},
+	{Name: "LABEL", Cardinality: "*", ValueTypes: []string{"text"}, ParameterValues: map[string][]string{"TYPE": v3AdrTypes}},
+	{Name: "TEL", Cardinality: "*", ValueTypes: []string{"text", "phone-number", "uri"}, ParameterValues: map[string][]string{"TYPE": v3TelTypes}},
+	{Name: "EMAIL", Cardinality: "*", ValueTypes: []string{"text"}, ParameterValues: map[string][]string{"TYPE": v3EmailTypes}},
+	{Name: "MAILER", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "TZ", Cardinality: "*1", ValueTypes: []string{"utc-offset", "text"}},
+	{Name: "GEO", Cardinality: "*", ValueTypes: []string{"float"}},
+	{Name: "TITLE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "ROLE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "LOGO", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "AGENT", Cardinality: "*", ValueTypes: []string{"text", "vcard", "uri"}},
+	{Name: "ORG", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "CATEGORIES", Cardinality: "*", MultipleValues: true, ValueTypes: []string{"text"}},
+	{Name: "NOTE", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "PRODID", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "REV", Cardinality: "*1", ValueTypes: []string{"date-time", "date"}},
+	{Name: "SORT-STRING", Cardinality: "*", ValueTypes: []string{"text"}},
+	{Name: "SOUND", Cardinality: "*", ValueTypes: []string{"binary", "uri"}},
+	{Name: "UID", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "URL", Cardinality: "*", ValueTypes: []string{"uri"}},
+	{Name: "CLASS", Cardinality: "*1", ValueTypes: []string{"text"}},
+	{Name: "KEY", Cardinality: "*", ValueTypes: []string{"binary", "text"}},
+	{Name: "IMPP", Cardinality: "*", ValueTypes: []string{"uri"}},
+	{Name: "FBURL", Cardinality: "*", ValueTypes: []string{"uri"}},
+	{Name: "CALADRURI", Cardinality: "*", ValueTypes: []string{"uri"}},
+	{Name: "CALURI", Cardinality: "*", ValueTypes: []string{"uri"}},
+}
+
+/**
+ * vcard 4.0 (RFC 6350 section 6)
+ */
+var v4PropertySchemas = []PropertySchema{
+	{Name: "BEGIN", Cardinality: "1", Parameters: []string{}},
+	{Name: "END", Cardinality: "1", Parameters: []string{}},
+	{Name: "VERSION", Cardinality: "1", Parameters: []string{}},
+	{Name: "SOURCE", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "ALTID", "MEDIATYPE"}},
+	{Name: "KIND", Cardinality: "*1", ValueTypes: []string{"text"}, Parameters: []string{"VALUE"}},
+	{Name: "XML", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "ALTID"}},
+	{Name: "FN", Cardinality: "1*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "TYPE", "LANGUAGE", "ALTID", "PID", "PREF"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "N", Cardinality: "*1", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "SORT-AS", "LANGUAGE", "ALTID"}, Components: nameComponents},
+	{Name: "NICKNAME", Cardinality: "*", MultipleValues: true, ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "TYPE", "LANGUAGE", "ALTID", "PID", "PREF"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "PHOTO", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "ALTID", "TYPE", "MEDIATYPE", "PREF", "PID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "BDAY", Cardinality: "*1", ValueTypes: []string{"date-and-or-time", "text"}, Parameters: []string{"VALUE", "ALTID", "CALSCALE", "LANGUAGE"}},
+	{Name: "ANNIVERSARY", Cardinality: "*1", ValueTypes: []string{"date-and-or-time", "text"}, Parameters: []string{"VALUE", "ALTID", "CALSCALE"}},
+	{Name: "GENDER", Cardinality: "*1", ValueTypes: []string{"text"}, Parameters: []string{"VALUE"}, Components: []string{"sex", "identity"}},
+	{Name: "ADR", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "LABEL", "LANGUAGE", "GEO", "TZ", "ALTID", "PID", "PREF", "TYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}, Components: addressComponents},
+	{Name: "TEL", Cardinality: "*", ValueTypes: []string{"text", "uri"}, Parameters: []string{"VALUE", "TYPE", "PID", "PREF", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4TelTypes}},
+	{Name: "EMAIL", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "IMPP", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "LANG", Cardinality: "*", ValueTypes: []string{"language-tag"}, Parameters: []string{"VALUE", "PID", "PREF", "ALTID", "TYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "TZ", Cardinality: "*", ValueTypes: []string{"text", "uri", "utc-offset"}, Parameters: []string{"VALUE", "ALTID", "PID", "PREF", "TYPE", "MEDIATYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "GEO", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "TITLE", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "LANGUAGE", "PID", "PREF", "ALTID", "TYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "ROLE", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "LANGUAGE", "PID", "PREF", "ALTID", "TYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "LOGO", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "LANGUAGE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "ORG", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "SORT-AS", "LANGUAGE", "PID", "PREF", "ALTID", "TYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "MEMBER", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "ALTID", "MEDIATYPE"}},
+	{Name: "RELATED", Cardinality: "*", ValueTypes: []string{"uri", "text"}, Parameters: []string{"VALUE", "PID", "PREF", "ALTID", "TYPE", "MEDIATYPE", "LANGUAGE"}, ParameterValues: map[string][]string{"TYPE": v4RelatedTypes}},
+	{Name: "CATEGORIES", Cardinality: "*", MultipleValues: true, ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "NOTE", Cardinality: "*", ValueTypes: []string{"text"}, Parameters: []string{"VALUE", "LANGUAGE", "PID", "PREF", "TYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "PRODID", Cardinality: "*1", ValueTypes: []string{"text"}, Parameters: []string{"VALUE"}},
+	{Name: "REV", Cardinality: "*1", ValueTypes: []string{"timestamp"}, Parameters: []string{"VALUE"}},
+	{Name: "SOUND", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "LANGUAGE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "UID", Cardinality: "*1", ValueTypes: []string{"uri", "text"}, Parameters: []string{"VALUE"}},
+	{Name: "CLIENTPIDMAP", Cardinality: "*", Parameters: []string{}, Components: []string{"sourceid", "uri"}},
+	{Name: "URL", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "KEY", Cardinality: "*", ValueTypes: []string{"uri", "text"}, Parameters: []string{"VALUE", "ALTID", "PID", "PREF", "TYPE", "MEDIATYPE"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "FBURL", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "CALADRURI", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+	{Name: "CALURI", Cardinality: "*", ValueTypes: []string{"uri"}, Parameters: []string{"VALUE", "PID", "PREF", "TYPE", "MEDIATYPE", "ALTID"}, ParameterValues: map[string][]string{"TYPE": v4Types}},
+}
+
+/**
+ * the schema of a property in a version (standard or registered with RegisterProperty)
+ * the schema is a copy: changing it does not change the registered one (see RegisterProperty)
+ */
+func GetPropertySchema(version string, name string) (PropertySchema, bool) {
+	s, ok := lookupPropertySchema(version, name)
+	return s.clone(), ok
+}
+
+/**
+ * the registered schema of a property, not copied: the callers must not change it
+ */
+func lookupPropertySchema(version string, name string) (PropertySchema, bool) {
+	schemaMutex.RLock()
+	defer schemaMutex.RUnlock()
+
+	s, ok := propertySchemas[version][strings.ToUpper(name)]
+	return s, ok
+}
+
+/**
+ * copy of the schema with its own slices and map (nil stays nil)
+ */
+func (s PropertySchema) clone() PropertySchema {
+	s.ValueTypes = copyStrings(s.ValueTypes)
+	s.Parameters = copyStrings(s.Parameters)
+	s.Components = copyStrings(s.Components)
+	if s.ParameterValues != nil {
+		values := make(map[string][]string, len(s.ParameterValues))
+		for k, v := range s.ParameterValues {
+			values[k] = copyStrings(v)
+		}
+		s.ParameterValues = values
+	}
+	return s
+}
+
+func copyStrings(v []string) []string {
+	if v == nil {
+		return nil
+	}
+	return append([]string{}, v...)
+}
+
+/**
+ * the schema of a standard parameter in a version
+ */
+func GetParameterSchema(version string, name string) (ParameterSchema, bool) {
+	s, ok := parameterSchemas[version][strings.ToUpper(name)]
+	return s, ok
+}
+
+/**
+ * register an extension property (X-...) for a version; a registered property is created, parsed,
+ * converted and validated with its schema as the standard ones
+ * registering the same name again replaces the schema
+ */
+func RegisterProperty(version string, schema PropertySchema) error {
+	name := strings.ToUpper(schema.Name)
+	if !strings.HasPrefix(name, "X-") {
+		return fmt.Errorf("vcard: only X- properties can be registered, not %q", schema.Name)
+	}
+	switch (schema.Cardinality) {
+		case "":
+			schema.Cardinality = "*"
+		case "1", "*1", "1*", "*":
+		default:
+			return fmt.Errorf("vcard: invalid cardinality %q for %s", schema.Cardinality, name)
+	}
+
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+
+	schemas, ok := propertySchemas[version]
+	if !ok {
+		return fmt.Errorf("vcard: unsupported version %q", version)
+	}
+	// the slices and the map are copied, so that the caller can not change the registered schema;
+	// the names are upper case and the parameter values lower case, as in the standard schemas
+	schema = schema.clone()
+	schema.Name = name
+	for i, p := range schema.Parameters {
+		schema.Parameters[i] = strings.ToUpper(p)
+	}
+	if schema.ParameterValues != nil {
+		values := make(map[string][]string, len(schema.ParameterValues))
+		for k, v := range schema.ParameterValues {
+			k = strings.ToUpper(k)
+			for _, value := range v {
+				values[k] = append(values[k], strings.ToLower(value))
+			}
+		}
+		schema.ParameterValues = values
+	}
+	schemas[name] = schema
+	return nil
+}
+
+/**
+ * true if a specification defines the property (in any version)
+ */
+func isStandardProperty(name string) bool {
+	return standardProperties[strings.ToUpper(name)]
+}
+
+/**
+ * true if the property exists in a version; X- and unknown properties are allowed in all the versions
+ */
+func isPropertyDefined(version string, name string) bool {
+	if !isStandardProperty(name) {
+		return true
+	}
+	_, ok := lookupPropertySchema(version, name)
+	return ok
+}
+
+/**
+ * the properties that must be present in a card (cardinality 1 or 1*), sorted by name
+ */
+func requiredProperties(version string) []string {
+	schemaMutex.RLock()
+	defer schemaMutex.RUnlock()
+
+	var names []string
+	for name, s := range propertySchemas[version] {
+		switch name {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+		if s.Cardinality == "1" || s.Cardinality == "1*" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
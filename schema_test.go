@@ -0,0 +1,86 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestRegisterPropertyCopiesSchema(t *testing.T) {
+	params := []string{"type", "pref"}
+	components := []string{"building", "floor"}
+	err := RegisterProperty("4.0", PropertySchema{
+		Name: "X-TEST-COPY",
+		Parameters: params,
+		Components: components,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params[0] != "type" {
+		t.Errorf("the caller's parameters are changed: %v", params)
+	}
+
+	params[1] = "LANGUAGE"
+	components[0] = "room"
+
+	s, ok := GetPropertySchema("4.0", "x-test-copy")
+	if !ok {
+		t.Fatal("the property is not registered")
+	}
+	if len(s.Parameters) != 2 || s.Parameters[0] != "TYPE" || s.Parameters[1] != "PREF" {
+		t.Errorf("parameters %v", s.Parameters)
+	}
+	if s.Components[0] != "building" {
+		t.Errorf("components %v", s.Components)
+	}
+
+	// nil stays nil: any parameter
+	if err := RegisterProperty("4.0", PropertySchema{Name: "X-TEST-ANY"}); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := GetPropertySchema("4.0", "X-TEST-ANY"); s.Parameters != nil {
+		t.Errorf("parameters %v", s.Parameters)
+	}
+}
+
+func TestGetPropertySchemaReturnsACopy(t *testing.T) {
+	s, _ := GetPropertySchema("4.0", "TEL")
+	s.ValueTypes[0] = "boolean"
+	s.Parameters[0] = "FOO"
+	s.ParameterValues["TYPE"][0] = "foo"
+
+	s, _ = GetPropertySchema("4.0", "TEL")
+	if s.ValueTypes[0] == "boolean" || s.Parameters[0] == "FOO" || s.ParameterValues["TYPE"][0] == "foo" {
+		t.Errorf("the registered schema is changed: %+v", s)
+	}
+}
+
+func TestRegisterPropertyParameterValues(t *testing.T) {
+	err := RegisterProperty("4.0", PropertySchema{
+		Name: "X-TEST-VALUES",
+		ParameterValues: map[string][]string{"type": {"Work", "home"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := NewVCardV4()
+	fn := card.CreateProperty("FN")
+	fn.AddValue(NewText("John"))
+	card.AddProperty(fn)
+	for _, value := range []string{"work", "other"} {
+		p := card.CreateProperty("X-TEST-VALUES")
+		p.AddValue(NewText(value))
+		card.AddPropertyParameter(p, "TYPE", []string{value})
+		card.AddProperty(p)
+	}
+
+	var warnings int
+	for _, e := range Validate(card) {
+		if e.Property == "X-TEST-VALUES" && e.Parameter == "TYPE" {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("%d TYPE warnings, want 1 (for \"other\")", warnings)
+	}
+}
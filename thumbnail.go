@@ -0,0 +1,232 @@
+/**
+ * reduced copies of the inline images (PHOTO, LOGO), so that the cards stay within the sizes accepted by the phones
+ * the JPEG, PNG and GIF images are decoded with the standard image packages, downscaled with an area average and encoded again
+ */
+package vcard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+/**
+ * JPEG quality used when ThumbnailOptions.Quality is 0
+ */
+const DefaultThumbnailQuality = 85
+
+/**
+ * largest image (width x height) decoded when ThumbnailOptions.MaxPixels is 0
+ * the decoded image takes about 4 bytes per pixel: a small file may declare a huge image
+ */
+const DefaultMaxThumbnailPixels = 24 * 1000 * 1000
+
+const (
+	// the JPEG quality is lowered down to this value before the image is downscaled again to fit MaxBytes
+	minThumbnailQuality = 40
+
+	// an image is not downscaled below this width and height to fit MaxBytes
+	minThumbnailDimension = 16
+)
+
+/**
+ * the image can not be encoded within ThumbnailOptions.MaxBytes
+ */
+var ErrThumbnailTooLarge = errors.New("vcard: the image does not fit in the byte limit")
+
+/**
+ * the dimensions of the image exceed ThumbnailOptions.MaxPixels, it is not decoded
+ */
+var ErrImageTooLarge = errors.New("vcard: the image has too many pixels")
+
+/**
+ * errors of the images that could not be reduced, returned by Encoder.Encode
+ */
+type ThumbnailErrors []error
+
+func (e ThumbnailErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+type ThumbnailOptions struct {
+	// maximum width and height in pixels, the aspect ratio is kept; 0 keeps the dimensions
+	MaxDimension int
+
+	// JPEG quality between 1 and 100; 0 for DefaultThumbnailQuality
+	Quality int
+
+	// maximum size of the encoded image in bytes (before the base64 encoding); 0 for no limit
+	// the JPEG quality is lowered first, then the image is downscaled
+	MaxBytes int
+
+	// format of the reduced image: "jpeg", "png", "gif", or "" for the format of the original image
+	Format string
+
+	// largest image (width x height) that is decoded; 0 for DefaultMaxThumbnailPixels
+	MaxPixels int
+}
+
+/**
+ * reduced copy of an inline image; the image is returned as it is (copied) when it is within the limits and keeps its format
+ */
+func (v *MediaValue) Thumbnail(o ThumbnailOptions) (*MediaValue, error) {
+	data, err := v.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// the dimensions are read from the header before the image is decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("vcard: can not decode the image: %v", err)
+	}
+	maxPixels := o.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxThumbnailPixels
+	}
+	if config.Height > 0 && config.Width > maxPixels/config.Height {
+		return nil, ErrImageTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("vcard: can not decode the image: %v", err)
+	}
+
+	outFormat := format
+	switch (o.Format) {
+		case "jpeg", "png", "gif":
+			outFormat = o.Format
+		case "":
+		default:
+			return nil, fmt.Errorf("vcard: unsupported image format %q", o.Format)
+	}
+	quality := o.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultThumbnailQuality
+	}
+
+	bounds := img.Bounds()
+	width, height := fitDimensions(bounds.Dx(), bounds.Dy(), o.MaxDimension)
+	if width == bounds.Dx() && height == bounds.Dy() && outFormat == format && (o.MaxBytes <= 0 || len(data) <= o.MaxBytes) {
+		return copyValue(v).(*MediaValue), nil
+	}
+
+	scaled := img
+	for {
+		if width != scaled.Bounds().Dx() || height != scaled.Bounds().Dy() {
+			scaled = scaleImage(img, width, height)
+		}
+		encoded, err := encodeImage(scaled, outFormat, quality)
+		if err != nil {
+			return nil, err
+		}
+		if o.MaxBytes <= 0 || len(encoded) <= o.MaxBytes {
+			return NewMediaFromBytes(encoded, "image/"+outFormat), nil
+		}
+
+		if outFormat == "jpeg" && quality > minThumbnailQuality {
+			quality -= 10
+			if quality < minThumbnailQuality {
+				quality = minThumbnailQuality
+			}
+			continue
+		}
+		if width <= minThumbnailDimension && height <= minThumbnailDimension {
+			return nil, ErrThumbnailTooLarge
+		}
+		width, height = max1(width*3/4), max1(height*3/4)
+	}
+}
+
+/**
+ * dimensions within limit x limit, with the same aspect ratio
+ */
+func fitDimensions(width int, height int, limit int) (int, int) {
+	if limit <= 0 || (width <= limit && height <= limit) {
+		return width, height
+	}
+	if width >= height {
+		return limit, max1(height * limit / width)
+	}
+	return max1(width * limit / height), limit
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+/**
+ * downscale with an area average: each pixel is the average of the source pixels it covers
+ */
+func scaleImage(src image.Image, width int, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy0 := b.Min.Y + y*b.Dy()/height
+		sy1 := b.Min.Y + (y+1)*b.Dy()/height
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < width; x++ {
+			sx0 := b.Min.X + x*b.Dx()/width
+			sx1 := b.Min.X + (x+1)*b.Dx()/width
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// RGBA() values are alpha premultiplied, as the RGBA pixels
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+/**
+ * the JPEG images have no transparency: the image is drawn on a white background
+ */
+func encodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch (format) {
+		case "jpeg":
+			opaque := image.NewRGBA(img.Bounds())
+			draw.Draw(opaque, opaque.Bounds(), image.White, image.Point{}, draw.Src)
+			draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+			err = jpeg.Encode(&buf, opaque, &jpeg.Options{Quality: quality})
+		case "png":
+			err = png.Encode(&buf, img)
+		case "gif":
+			err = gif.Encode(&buf, img, nil)
+		default:
+			err = fmt.Errorf("vcard: unsupported image format %q", format)
+	}
+	return buf.Bytes(), err
+}
@@ -0,0 +1,104 @@
+package vcard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testPNG(width int, height int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	return buf.Bytes()
+}
+
+func TestBuildDropsImageThatCanNotBeReduced(t *testing.T) {
+	card := NewVCardV3()
+	fn := card.CreateProperty("FN")
+	fn.AddValue(NewText("John"))
+	card.AddProperty(fn)
+	photo := card.CreateProperty("PHOTO")
+	photo.AddValue(NewMediaFromBytes([]byte("not an image"), "image/jpeg"))
+	card.AddProperty(photo)
+
+	b := NewBuilder(card)
+	b.SetThumbnailOptions(&ThumbnailOptions{MaxDimension: 100})
+	out := b.Build()
+	if strings.Contains(out, "PHOTO") {
+		t.Errorf("the image that can not be reduced is written: %q", out)
+	}
+	if !strings.Contains(out, "FN:John") {
+		t.Errorf("the other properties are not written: %q", out)
+	}
+	if len(b.GetThumbnailErrors()) != 1 {
+		t.Errorf("thumbnail errors: %v", b.GetThumbnailErrors())
+	}
+
+	b.SetThumbnailOptions(nil)
+	if out := b.Build(); !strings.Contains(out, "PHOTO") || len(b.GetThumbnailErrors()) != 0 {
+		t.Errorf("the image is not written without thumbnail options: %q", out)
+	}
+}
+
+func TestThumbnailOptionsOfCardAndEncoder(t *testing.T) {
+	card := NewVCardV4()
+	fn := card.CreateProperty("FN")
+	fn.AddValue(NewText("John"))
+	card.AddProperty(fn)
+	logo := card.CreateProperty("LOGO")
+	logo.AddValue(NewMediaFromBytes([]byte("not an image"), "image/png"))
+	card.AddProperty(logo)
+
+	if !strings.Contains(card.Build(), "LOGO") {
+		t.Errorf("the image is not written without thumbnail options")
+	}
+
+	card.SetThumbnailOptions(&ThumbnailOptions{MaxDimension: 100})
+	if strings.Contains(card.Build(), "LOGO") {
+		t.Errorf("the options of the card are not used by Build")
+	}
+	if len(card.GetThumbnailErrors()) != 1 {
+		t.Errorf("card thumbnail errors: %v", card.GetThumbnailErrors())
+	}
+
+	card.SetThumbnailOptions(nil)
+	var out strings.Builder
+	e := NewEncoder(&out)
+	e.SetThumbnailOptions(&ThumbnailOptions{MaxDimension: 100})
+	if errs, ok := e.Encode(card).(ThumbnailErrors); !ok || len(errs) != 1 {
+		t.Errorf("Encode does not return the thumbnail errors")
+	}
+	if strings.Contains(out.String(), "LOGO") || !strings.Contains(out.String(), "FN:John") {
+		t.Errorf("encoded card: %q", out.String())
+	}
+	if err := e.Encode(card); err == nil {
+		t.Errorf("the second Encode does not return the thumbnail error")
+	}
+	if strings.Count(out.String(), "BEGIN:VCARD") != 2 {
+		t.Errorf("the thumbnail error stops the encoder: %q", out.String())
+	}
+}
+
+func TestThumbnailRejectsImagesWithTooManyPixels(t *testing.T) {
+	small := NewMediaFromBytes(testPNG(10, 10), "")
+	if _, err := small.Thumbnail(ThumbnailOptions{MaxDimension: 5, MaxPixels: 50}); err != ErrImageTooLarge {
+		t.Errorf("10x10 image with MaxPixels 50: %v", err)
+	}
+	if _, err := small.Thumbnail(ThumbnailOptions{MaxDimension: 5, MaxPixels: 100}); err != nil {
+		t.Errorf("10x10 image with MaxPixels 100: %v", err)
+	}
+
+	// a small file that declares a 100000 x 100000 image: the header is checked before any decoding
+	data := testPNG(1, 1)
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	if _, err := NewMediaFromBytes(data, "").Thumbnail(ThumbnailOptions{MaxDimension: 100}); err != ErrImageTooLarge {
+		t.Errorf("100000x100000 image: %v", err)
+	}
+}
@@ -0,0 +1,394 @@
+/**
+ * card validation: required properties, cardinality, value types and parameters
+ */
+package vcard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the card is not valid for its version
+	SeverityError = "error"
+
+	// the card can be used, but it is not strictly conforming (ex: unknown parameters)
+	SeverityWarning = "warning"
+)
+
+/**
+ * a problem found by Validate
+ */
+type ValidationError struct {
+	// property name; empty for problems about the whole card
+	Property string
+
+	// parameter name; empty if the problem is not about a parameter
+	Parameter string
+
+	// SeverityError or SeverityWarning
+	Severity string
+
+	// the specification rule that is violated (ex: "RFC 6350 6.2.1")
+	Rule string
+
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	var s strings.Builder
+	s.WriteString(e.Severity)
+	s.WriteString(": ")
+	if e.Property != "" {
+		s.WriteString(e.Property)
+		if e.Parameter != "" {
+			s.WriteString(";")
+			s.WriteString(e.Parameter)
+		}
+		s.WriteString(": ")
+	}
+	s.WriteString(e.Message)
+	if e.Rule != "" {
+		s.WriteString(" (")
+		s.WriteString(e.Rule)
+		s.WriteString(")")
+	}
+	return s.String()
+}
+
+/**
+ * specification and sections of the rules checked by Validate
+ */
+var validationSpecs = map[string]string{
+	"2.1": "vCard 2.1",
+	"3.0": "RFC 2426",
+	"4.0": "RFC 6350",
+}
+
+var validationSections = map[string]map[string]string{
+	"2.1": {
+		"FN": "2.2.1",
+		"N": "2.2.2",
+		"VERSION": "2.7.6",
+		"cardinality": "2.1",
+		"group": "2.1",
+		"parameters": "2.1",
+		"properties": "2",
+		"values": "2.1",
+	},
+	"3.0": {
+		"FN": "3.1.1",
+		"group": "4",
+		"N": "3.1.2",
+		"VERSION": "3.6.9",
+		"cardinality": "5",
+		"parameters": "4",
+		"properties": "3",
+		"values": "4",
+	},
+	"4.0": {
+		"FN": "6.2.1",
+		"N": "6.2.2",
+		"VERSION": "6.7.9",
+		"cardinality": "3.3",
+		"group": "3.3",
+		"parameters": "5",
+		"properties": "6",
+		"values": "4",
+	},
+}
+
+func validationRule(version string, topic string) string {
+	spec, ok := validationSpecs[version]
+	if !ok {
+		return ""
+	}
+	if section, ok := validationSections[version][topic]; ok {
+		return spec + " " + section
+	}
+	return spec
+}
+
+/**
+ * check a card against the rules of its version
+ * return all the problems found; a card is valid when no problem has SeverityError
+ */
+func Validate(card IVCard) []ValidationError {
+	v := &validator{
+		card: card,
+		version: card.GetVersion(),
+	}
+	v.validate()
+	return v.errors
+}
+
+/**
+ * true if none of the problems is an error
+ */
+func IsValid(errors []ValidationError) bool {
+	for _, e := range errors {
+		if e.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+type validator struct {
+	card IVCard
+	version string
+	errors []ValidationError
+}
+
+func (v *validator) report(severity string, property string, parameter string, topic string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		Property: property,
+		Parameter: parameter,
+		Severity: severity,
+		Rule: validationRule(v.version, topic),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate() {
+	if _, ok := validationSpecs[v.version]; !ok {
+		v.report(SeverityError, "VERSION", "", "VERSION", "unsupported version %q", v.version)
+		return
+	}
+
+	// names in the order of the card, so the report is stable
+	var names []string
+	counts := map[string]int{}
+	for _, p := range v.card.GetProperties() {
+		switch p.GetName() {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+		if counts[p.GetName()] == 0 {
+			names = append(names, p.GetName())
+		}
+		counts[p.GetName()]++
+		v.validateProperty(p)
+	}
+
+	// the representations with the same ALTID are one property for the cardinality
+	instances := map[string]int{}
+	for _, name := range names {
+		alternatives := GetAlternatives(v.card, name)
+		instances[name] = len(alternatives)
+		for _, group := range alternatives {
+			v.validateAlternatives(group)
+		}
+	}
+
+	for _, name := range requiredProperties(v.version) {
+		if counts[name] == 0 {
+			v.report(SeverityError, name, "", name, "required property is missing")
+		}
+	}
+
+	for _, name := range names {
+		switch v.card.CreateProperty(name).GetCardinality() {
+			case "1", "*1":
+				if instances[name] > 1 {
+					v.report(SeverityError, name, "", "cardinality", "property may appear only once, found %d", instances[name])
+				}
+		}
+	}
+}
+
+/**
+ * the representations of a property are told apart by their language
+ */
+func (v *validator) validateAlternatives(alternatives []IProperty) {
+	if len(alternatives) < 2 {
+		return
+	}
+	languages := map[string]bool{}
+	for _, p := range alternatives {
+		language := strings.ToLower(languageOf(p))
+		if languages[language] {
+			v.report(SeverityWarning, p.GetName(), "ALTID", "parameters", "representations with ALTID %s have the same language %q", altIdOf(p), language)
+			return
+		}
+		languages[language] = true
+	}
+}
+
+func (v *validator) validateProperty(p IProperty) {
+	name := p.GetName()
+
+	// group = 1*(ALPHA / DIGIT / "-")
+	for _, c := range p.GetGroup() {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			v.report(SeverityError, name, "", "group", "invalid character %q in group %q", c, p.GetGroup())
+			break
+		}
+	}
+
+	if !isPropertyDefined(v.version, name) {
+		v.report(SeverityWarning, name, "", "properties", "property not defined in vCard %s", v.version)
+	}
+
+	values := p.GetValue()
+	if len(values) == 0 {
+		v.report(SeverityWarning, name, "", "values", "property has no value")
+	}
+	if len(values) > 1 && !p.GetAllowMultipleValues() {
+		v.report(SeverityError, name, "", "values", "property allows a single value, found %d", len(values))
+	}
+
+	for _, param := range p.GetParameters() {
+		v.validateParameter(p, param)
+	}
+
+	valueType := p.GetValueType()
+	if p.GetParameter("VALUE") == nil && len(p.GetAcceptedValueTypes()) == 0 {
+		// property not created by the card
+		valueType = defaultValueType(name, v.version)
+	}
+
+	schema, _ := lookupPropertySchema(v.version, name)
+	accepted := schema.ValueTypes
+	if p.GetParameter("VALUE") != nil && v.version != "2.1" && len(accepted) > 0 &&
+		!strings.HasPrefix(valueType, "x-") && !containsString(accepted, valueType) {
+		v.report(SeverityError, name, "VALUE", "values", "value type %s not accepted by the property", valueType)
+	}
+
+	for _, d := range values {
+		if !d.Validate() {
+			v.report(SeverityError, name, "", "values", "invalid %s value", strings.ToLower(d.GetType()))
+			continue
+		}
+		if valueType != "" && !validData(d, valueType, v.version != "4.0") {
+			v.report(SeverityError, name, "", "values", "value %q is not a valid %s", d.GetString(), valueType)
+		}
+	}
+}
+
+func (v *validator) validateParameter(p IProperty, param IParameter) {
+	name := param.GetName()
+	property := p.GetName()
+
+	if err := param.ValidateError(); err != nil {
+		v.report(SeverityError, property, name, "parameters", "%v", err)
+		return
+	}
+
+	if strings.HasPrefix(name, "X-") {
+		return
+	}
+
+	paramSchema, ok := GetParameterSchema(v.version, name)
+	if !ok {
+		v.report(SeverityWarning, property, name, "parameters", "parameter not defined in vCard %s", v.version)
+		return
+	}
+	if schema, ok := lookupPropertySchema(v.version, property); ok && !schema.AllowsParameter(name) {
+		v.report(SeverityWarning, property, name, "parameters", "parameter not defined for the property")
+	}
+
+	values := param.GetValue()
+	if len(values) > 1 && !paramSchema.MultipleValues {
+		v.report(SeverityError, property, name, "parameters", "parameter allows a single value, found %d", len(values))
+	}
+
+	if paramSchema.Values != nil {
+		for _, value := range values {
+			if !strings.HasPrefix(strings.ToLower(value), "x-") && !containsString(paramSchema.Values, strings.ToLower(value)) {
+				v.report(SeverityError, property, name, "parameters", "unknown value %q", value)
+			}
+		}
+	}
+
+	// values of the parameter accepted by the property (ex: TYPE of TEL); iana tokens are allowed, so only a warning
+	if schema, ok := lookupPropertySchema(v.version, property); ok && schema.ParameterValues[name] != nil {
+		for _, value := range values {
+			if !strings.HasPrefix(strings.ToLower(value), "x-") && !containsString(schema.ParameterValues[name], strings.ToLower(value)) {
+				v.report(SeverityWarning, property, name, "parameters", "unknown value %q for the property", value)
+			}
+		}
+	}
+
+	switch name {
+		case "PREF":
+			for _, value := range values {
+				if pref, err := strconv.Atoi(value); err != nil || pref < 1 || pref > 100 {
+					v.report(SeverityError, property, name, "parameters", "must be an integer between 1 and 100, found %q", value)
+				}
+			}
+		case "PID":
+			for _, value := range values {
+				if !pidValueRegexp.MatchString(value) {
+					v.report(SeverityError, property, name, "parameters", "invalid property id %q", value)
+				}
+			}
+		case "GEO":
+			for _, value := range values {
+				if !IsUri(value) {
+					v.report(SeverityError, property, name, "parameters", "must be an uri, found %q", value)
+				}
+			}
+	}
+}
+
+/**
+ * check a value against a value type; the text values are checked with validValue
+ */
+func validData(d IData, valueType string, extended bool) bool {
+	if t, ok := d.(*TextValue); ok {
+		return validValue(t.GetValue(), valueType, extended)
+	}
+	return ValidateData(d, valueType)
+}
+
+/**
+ * check a text value against a value type
+ * with extended set, the vcard 3.0 dates, times and utc offsets may use the ISO 8601 extended format (1996-04-15, 10:22:00, -05:00)
+ */
+func validValue(s string, valueType string, extended bool) bool {
+	switch valueType {
+		case "date", "time", "date-time":
+			if extended {
+				s = basicDateTime(s)
+				if valueType == "date-time" {
+					return IsDatetime(s) || IsTimestamp(s)
+				}
+			}
+		case "utc-offset":
+			if extended {
+				s = strings.ReplaceAll(s, ":", "")
+			}
+		case "date-and-or-time", "timestamp", "uri", "boolean", "integer", "float":
+		default:
+			// text and the types without a validator
+			return true
+	}
+	return ValidateData(NewText(s), valueType)
+}
+
+/**
+ * ISO 8601 extended format to basic format: the "-" of the date and the ":" of the time are removed
+ */
+func basicDateTime(s string) string {
+	date, time := s, ""
+	if i := strings.IndexByte(s, 'T'); i >= 0 {
+		date, time = s[:i], s[i:]
+	} else if strings.Contains(s, ":") {
+		date, time = "", s
+	}
+	if !strings.HasPrefix(date, "--") {
+		date = strings.ReplaceAll(date, "-", "")
+	}
+	return date + strings.ReplaceAll(time, ":", "")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParameterValidateError(t *testing.T) {
+	p := NewParameter("TYPE")
+	if p.Validate() || p.ValidateError() == nil {
+		t.Errorf("parameter without value is valid")
+	}
+	p.AddValue("work")
+	if !p.Validate() || p.ValidateError() != nil {
+		t.Errorf("TYPE=work: %v", p.ValidateError())
+	}
+	p.AddValue("a\x01b")
+	if err := p.ValidateError(); p.Validate() || err == nil || !strings.Contains(err.Error(), "invalid character") {
+		t.Errorf("control character: %v", err)
+	}
+}
+
+func TestValidationRulesHaveSections(t *testing.T) {
+	for _, version := range []string{"2.1", "3.0", "4.0"} {
+		for _, topic := range []string{"VERSION", "FN", "N", "cardinality", "group", "parameters", "properties", "values"} {
+			rule := validationRule(version, topic)
+			if rule == validationSpecs[version] {
+				t.Errorf("%s %s: rule without section %q", version, topic, rule)
+			}
+		}
+	}
+
+	card, err := Parse("BEGIN:VCARD\r\nVERSION:2.1\r\nFN:John\r\nX-FOO:bar\r\nEND:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, e := range Validate(card) {
+		rules = append(rules, e.Rule)
+	}
+	if len(rules) == 0 || rules[0] != "vCard 2.1 2.2.2" {
+		t.Errorf("vcard 2.1 without N: %v", rules)
+	}
+}
+
+func TestValidateCard(t *testing.T) {
+	card := NewVCardV3()
+	for _, value := range []string{"19850412", "1986-01-01"} {
+		bday := card.CreateProperty("BDAY")
+		bday.AddValue(NewText(value))
+		// bypass the cardinality of AddProperty
+		bday.SetCardinality("*")
+		card.AddProperty(bday)
+	}
+	url := card.CreateProperty("URL")
+	url.AddValue(NewText("not an uri"))
+	card.AddProperty(url)
+	geo := card.CreateProperty("GEO")
+	geo.AddValue(NewGeoFromFloats(37.386013, -122.082932))
+	card.AddProperty(geo)
+	tel := card.CreateProperty("TEL")
+	tel.AddValue(NewText("+1 555 0100"))
+	card.AddPropertyParameter(tel, "TYPE", []string{"work", "fridge"})
+	card.AddProperty(tel)
+
+	errors := Validate(card)
+	if IsValid(errors) {
+		t.Errorf("the card is valid: %v", errors)
+	}
+
+	find := func(property string, parameter string, severity string) *ValidationError {
+		for i, e := range errors {
+			if e.Property == property && e.Parameter == parameter && e.Severity == severity {
+				return &errors[i]
+			}
+		}
+		return nil
+	}
+	for _, name := range []string{"FN", "N"} {
+		if e := find(name, "", SeverityError); e == nil || e.Rule == "" {
+			t.Errorf("missing %s is not reported: %v", name, errors)
+		}
+	}
+	if e := find("BDAY", "", SeverityError); e == nil || e.Rule != "RFC 2426 5" {
+		t.Errorf("BDAY cardinality: %+v", e)
+	}
+	if e := find("URL", "", SeverityError); e == nil || e.Rule != "RFC 2426 4" {
+		t.Errorf("URL value: %+v", e)
+	}
+	if e := find("GEO", "", SeverityError); e != nil {
+		t.Errorf("valid GEO: %+v", e)
+	}
+	if e := find("TEL", "TYPE", SeverityWarning); e == nil || !strings.Contains(e.Message, "fridge") {
+		t.Errorf("TEL TYPE: %+v", e)
+	}
+
+	// a complete card
+	card = NewVCardV3()
+	for name, value := range map[string]IData{"FN": NewText("John"), "N": NewName()} {
+		p := card.CreateProperty(name)
+		p.AddValue(value)
+		card.AddProperty(p)
+	}
+	if errors := Validate(card); !IsValid(errors) {
+		t.Errorf("valid card: %v", errors)
+	}
+}
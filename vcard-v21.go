@@ -0,0 +1,258 @@
+package vcard
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * vcard 2.1 (versit consortium specification)
+ * the values are kept decoded (utf-8); quoted-printable and base64 encodings are applied by the builder
+ */
+type VCardV21 struct {
+	baseVCard
+}
+
+/**
+ * create property
+ */
+func (vc *VCardV21) CreateProperty(name string) IProperty {
+	return vc.createProperty(name, "2.1")
+}
+
+/**
+ * create a parameter and attach it to a property
+ * vcard 2.1 parameter values are upper case; ENCODING=b (vcard 3.0) is rewritten as BASE64 and VALUE=uri as URL
+ */
+func (vc *VCardV21) AddPropertyParameter(p IProperty, name string, value []string) {
+	param := newSchemaParameter(name, "2.1")
+
+	switch (param.GetName()) {
+		case "ENCODING":
+			value = upperValues(firstValue(value))
+			if len(value) > 0 {
+				switch value[0] {
+					case "B":
+						value[0] = "BASE64"
+					case "7BIT", "8BIT", "QUOTED-PRINTABLE", "BASE64":
+						// valid
+					default:
+						return
+				}
+			}
+		case "VALUE":
+			value = upperValues(firstValue(value))
+			if len(value) > 0 && value[0] == "URI" {
+				value[0] = "URL"
+			}
+		case "TYPE":
+			value = upperValues(value)
+		case "CHARSET":
+			value = upperValues(firstValue(value))
+		case "LANGUAGE":
+			value = firstValue(value)
+	}
+
+	if len(value) == 0 {
+		return
+	}
+
+	param.SetValue(value)
+
+	p.AddParameter(param)
+}
+
+func (vc *VCardV21) GetVersion() string {
+	return "2.1"
+}
+
+func (vc *VCardV21) Build() string {
+	builder := NewBuilder(vc)
+	s := builder.Build()
+	vc.thumbnailErrors = builder.GetThumbnailErrors()
+	return s
+}
+
+func upperValues(v []string) []string {
+	result := make([]string, len(v))
+	for i, s := range v {
+		result[i] = strings.ToUpper(s)
+	}
+	return result
+}
+
+/**
+ * convert a vcard 3.0 escaped value (see EscapeValue) to the vcard 2.1 form,
+ * where only the ";" of the compound values are escaped and the line breaks are kept as they are
+ */
+func escapedToV21(v string) string {
+	if !strings.Contains(v, "\\") {
+		return v
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) {
+			i++
+			switch (v[i]) {
+				case 'n', 'N':
+					s.WriteString("\r\n")
+				case ';':
+					s.WriteString("\\;")
+				default:
+					s.WriteByte(v[i])
+			}
+			continue
+		}
+		s.WriteByte(v[i])
+	}
+	return s.String()
+}
+
+/**
+ * convert a decoded vcard 2.1 value to the vcard 3.0 escaped form, so it can be read by the common value parsers
+ */
+func v21ToEscaped(v string) string {
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		switch (v[i]) {
+			case '\\':
+				if i+1 < len(v) && v[i+1] == ';' {
+					s.WriteString("\\;")
+					i++
+				} else {
+					s.WriteString("\\\\")
+				}
+			case ',':
+				s.WriteString("\\,")
+			case '\r':
+				if i+1 < len(v) && v[i+1] == '\n' {
+					i++
+				}
+				s.WriteString("\\n")
+			case '\n':
+				s.WriteString("\\n")
+			default:
+				s.WriteByte(v[i])
+		}
+	}
+	return s.String()
+}
+
+/**
+ * check if a vcard 2.1 value can not be written as it is (non ascii chars or line breaks)
+ */
+func needsQuotedPrintable(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] > 126 || (v[i] < 32 && v[i] != '\t') {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * encode a value as quoted-printable (RFC 2045); lines are broken with soft line breaks ("=" CRLF)
+ * so that no line is longer than 76 chars. offset is the length of the text already written on the first line
+ */
+func encodeQuotedPrintable(v string, offset int, lineEnding string) string {
+	const hex = "0123456789ABCDEF"
+	var s strings.Builder
+
+	lineLen := offset
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		var enc string
+		switch {
+			case c == '\t' || c == ' ':
+				// white space at the end of the value must be encoded
+				if i == len(v)-1 {
+					enc = "=" + string(hex[c>>4]) + string(hex[c&0x0f])
+				} else {
+					enc = string(c)
+				}
+			case c >= 33 && c <= 126 && c != '=':
+				enc = string(c)
+			default:
+				enc = "=" + string(hex[c>>4]) + string(hex[c&0x0f])
+		}
+		if lineLen+len(enc) > 75 {
+			s.WriteString("=" + lineEnding)
+			lineLen = 0
+		}
+		s.WriteString(enc)
+		lineLen += len(enc)
+	}
+	return s.String()
+}
+
+/**
+ * decode a quoted-printable value; soft line breaks must already be removed
+ * invalid sequences are kept as they are
+ */
+func decodeQuotedPrintable(v string) string {
+	if !strings.Contains(v, "=") {
+		return v
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '=' && i+2 < len(v) {
+			if b, err := strconv.ParseUint(v[i+1:i+3], 16, 8); err == nil {
+				s.WriteByte(byte(b))
+				i += 2
+				continue
+			}
+		}
+		s.WriteByte(v[i])
+	}
+	return s.String()
+}
+
+/**
+ * windows-1252 chars for the bytes 0x80 - 0x9f (0 for the undefined ones)
+ */
+var windows1252 = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+/**
+ * convert a value from the CHARSET of the property to utf-8; false if the value is not utf-8
+ * only the western charsets are converted (ISO-8859-1, WINDOWS-1252); other values are returned unchanged
+ */
+func decodeCharset(v string, charset string) (string, bool) {
+	charset = strings.ToUpper(charset)
+	switch (charset) {
+		case "UTF-8", "US-ASCII":
+			return v, true
+		case "ISO-8859-1", "LATIN1", "WINDOWS-1252", "CP1252":
+		default:
+			return v, false
+	}
+
+	var s strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c >= 0x80 && c <= 0x9f && (charset == "WINDOWS-1252" || charset == "CP1252") && windows1252[c-0x80] != 0 {
+			s.WriteRune(windows1252[c-0x80])
+		} else {
+			s.WriteRune(rune(c))
+		}
+	}
+	return s.String(), true
+}
+
+/**
+ * true if the values of a property with this CHARSET are utf-8 (see decodeCharset)
+ */
+func isUtf8Charset(charset string) bool {
+	_, ok := decodeCharset("", charset)
+	return ok
+}
+
+func NewVCardV21() *VCardV21 {
+	v := VCardV21{}
+	v.SetAddPropertyScenario("overwrite")
+	return &v
+}
@@ -0,0 +1,104 @@
+package vcard
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/**
+ * vcard 4.0 (RFC 6350)
+ */
+type VCardV4 struct {
+	baseVCard
+}
+
+/**
+ * create property
+ * cardinality, value types and multiple values from the vcard 4.0 schema (RFC 6350 section 6, see schema.go)
+ */
+func (vc *VCardV4) CreateProperty(name string) IProperty {
+	return vc.createProperty(name, "4.0")
+}
+
+var pidValueRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+/**
+ * create a parameter and attach it to a property
+ * invalid values of the vcard 4.0 parameters are dropped; a parameter left without values is not added
+ */
+func (vc *VCardV4) AddPropertyParameter(p IProperty, name string, value []string) {
+	param := newSchemaParameter(name, "4.0")
+
+	switch (param.GetName()) {
+		case "ENCODING", "CHARSET":
+			// not defined in vcard 4.0: the values are always utf-8 text or uris
+			return
+		case "PREF":
+			// an integer between 1 and 100
+			value = firstValue(value)
+			if len(value) > 0 {
+				pref, err := strconv.Atoi(value[0])
+				if err != nil || pref < 1 || pref > 100 {
+					return
+				}
+			}
+		case "PID":
+			var pids []string
+			for _, v := range value {
+				if pidValueRegexp.MatchString(v) {
+					pids = append(pids, v)
+				}
+			}
+			value = pids
+		case "TYPE":
+			value = lowerValues(value)
+		case "VALUE", "CALSCALE", "MEDIATYPE":
+			value = lowerValues(firstValue(value))
+		case "ALTID", "LANGUAGE", "GEO", "TZ":
+			value = firstValue(value)
+	}
+
+	if len(value) == 0 {
+		return
+	}
+
+	param.SetValue(value)
+
+	p.AddParameter(param)
+}
+
+func (vc *VCardV4) GetVersion() string {
+	return "4.0"
+}
+
+func (vc *VCardV4) Build() string {
+	builder := NewBuilder(vc)
+	s := builder.Build()
+	vc.thumbnailErrors = builder.GetThumbnailErrors()
+	return s
+}
+
+/**
+ * keep only the first value of a single value parameter
+ */
+func firstValue(v []string) []string {
+	if len(v) > 1 {
+		return v[:1]
+	}
+	return v
+}
+
+func lowerValues(v []string) []string {
+	result := make([]string, len(v))
+	for i, s := range v {
+		result[i] = strings.ToLower(s)
+	}
+	return result
+}
+
+func NewVCardV4() *VCardV4 {
+	v := VCardV4{}
+	v.SetAddPropertyScenario("overwrite")
+	return &v
+}
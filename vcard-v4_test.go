@@ -0,0 +1,84 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVCardV4Cardinalities(t *testing.T) {
+	vc := NewVCardV4()
+	tests := map[string]string{
+		"FN": "1*",
+		"N": "*1",
+		"KIND": "*1",
+		"GENDER": "*1",
+		"BDAY": "*1",
+		"MEMBER": "*",
+		"EMAIL": "*",
+	}
+	for name, want := range tests {
+		if got := vc.CreateProperty(name).GetCardinality(); got != want {
+			t.Errorf("%s cardinality %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestVCardV4Parameters(t *testing.T) {
+	vc := NewVCardV4()
+	tests := []struct {
+		name string
+		values []string
+		want []string
+	}{
+		{"PREF", []string{"1"}, []string{"1"}},
+		{"PREF", []string{"0"}, nil},
+		{"PREF", []string{"101"}, nil},
+		{"PID", []string{"1.1", "x", "2"}, []string{"1.1", "2"}},
+		{"TYPE", []string{"WORK", "Voice"}, []string{"work", "voice"}},
+		{"MEDIATYPE", []string{"Image/JPEG", "image/png"}, []string{"image/jpeg"}},
+		{"CALSCALE", []string{"GREGORIAN"}, []string{"gregorian"}},
+		{"ALTID", []string{"1", "2"}, []string{"1"}},
+		{"SORT-AS", []string{"Doe", "John"}, []string{"Doe", "John"}},
+		{"GEO", []string{"geo:1,2"}, []string{"geo:1,2"}},
+		{"TZ", []string{"Europe/Paris"}, []string{"Europe/Paris"}},
+		{"ENCODING", []string{"b"}, nil},
+		{"CHARSET", []string{"UTF-8"}, nil},
+	}
+	for _, tt := range tests {
+		p := vc.CreateProperty("TEL")
+		vc.AddPropertyParameter(p, tt.name, tt.values)
+		var got []string
+		if param := p.GetParameter(tt.name); param != nil {
+			got = param.GetValue()
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s=%v: %v, want %v", tt.name, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestVCardV4Build(t *testing.T) {
+	vc := NewVCardV4()
+	for _, name := range []string{"FN", "KIND"} {
+		p := vc.CreateProperty(name)
+		p.AddValue(NewText(map[string]string{"FN": "ACME", "KIND": "org"}[name]))
+		vc.AddProperty(p)
+	}
+
+	out := vc.Build()
+	if !strings.HasPrefix(out, "BEGIN:VCARD\r\nVERSION:4.0\r\n") || !strings.HasSuffix(out, "\r\nEND:VCARD") {
+		t.Errorf("4.0 card: %q", out)
+	}
+	if !strings.Contains(out, "\r\nKIND:org\r\n") {
+		t.Errorf("KIND: %q", out)
+	}
+
+	card, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := card.(*VCardV4); !ok || card.GetVersion() != "4.0" {
+		t.Errorf("parsed card %T %s", card, card.GetVersion())
+	}
+}
@@ -0,0 +1,321 @@
+/**
+ * property list management shared by all vcard versions
+ */
+package vcard
+
+import (
+	"fmt"
+	"strings"
+)
+
+type baseVCard struct {
+	properties []IProperty
+	/**
+	 * possible values:
+	 *	 - ignore - if a property with cardinality 1 or *1 is already set and is added for the second time, the new added is ignored (the first property is kept)
+	 *	 - overwrite - if a property with cardinality 1 or *1 is already set and is added for the second time, the old one is overwritten.
+	 *  default: overwrite
+	 */
+	 addPropertyScenario string
+
+	// strict mode: the properties reject the values that don't match their value type (see VCardProperty.SetStrict)
+	strict bool
+
+	// the inline PHOTO and LOGO images are reduced with these options when the card is built; nil keeps the images
+	thumbnailOptions *ThumbnailOptions
+
+	// errors of the images that could not be reduced by the last Build
+	thumbnailErrors []error
+}
+
+/**
+ * enable the strict mode for the properties created after the call
+ */
+func (b *baseVCard) SetStrict(v bool) {
+	b.strict = v
+}
+
+func (b *baseVCard) GetStrict() bool {
+	return b.strict
+}
+
+/**
+ * reduce the inline PHOTO and LOGO images when the card is built (see Builder.SetThumbnailOptions)
+ */
+func (b *baseVCard) SetThumbnailOptions(o *ThumbnailOptions) {
+	b.thumbnailOptions = o
+}
+
+func (b *baseVCard) GetThumbnailOptions() *ThumbnailOptions {
+	return b.thumbnailOptions
+}
+
+/**
+ * errors of the images that could not be reduced by the last Build; their properties were not written
+ */
+func (b *baseVCard) GetThumbnailErrors() []error {
+	return b.thumbnailErrors
+}
+
+/**
+ * create a property with the rules of its schema (see schema.go); properties without schema
+ * may appear any number of times and have a single text value
+ */
+func (b *baseVCard) createProperty(name string, version string) IProperty {
+	p := NewProperty(name)
+	if p == nil {
+		return nil
+	}
+
+	schema, ok := lookupPropertySchema(version, p.GetName())
+	if !ok {
+		schema.Cardinality = "*"
+	}
+	p.SetCardinality(schema.Cardinality)
+	p.SetAllowMultipleValues(schema.MultipleValues)
+	p.SetAcceptedValueTypes(copyStrings(schema.ValueTypes))
+	p.SetAcceptedParameters(schema.Parameters)
+
+	switch (p.GetName()) {
+		case "BEGIN", "END", "PROFILE":
+			p.AddValue(NewText("VCARD"))
+		case "VERSION":
+			p.AddValue(NewText(version))
+	}
+
+	p.SetStrict(b.strict)
+	return p
+}
+
+/**
+ * component names of a structured property (see PropertySchema.Components)
+ */
+func propertyComponents(p IProperty) []string {
+	for _, version := range []string{"4.0", "3.0", "2.1"} {
+		if s, ok := lookupPropertySchema(version, p.GetName()); ok && len(s.Components) > 0 {
+			return s.Components
+		}
+	}
+	return nil
+}
+
+/**
+ * create a parameter with the rules of its schema: parameters without schema (X-...) may have more values
+ */
+func newSchemaParameter(name string, version string) *Parameter {
+	param := NewParameter(name)
+	schema, ok := GetParameterSchema(version, param.GetName())
+	param.SetAllowMultipleValues(!ok || schema.MultipleValues)
+	return param
+}
+
+func (b *baseVCard) SetAddPropertyScenario(v string) {
+	if v != "ignore" {
+		v = "overwrite"
+	}
+	b.addPropertyScenario = v;
+}
+
+func (b *baseVCard) GetAddPropertyScenario() string {
+	v := b.addPropertyScenario
+	if v!= "ignore" {
+		v = "overwrite"
+	}
+	return v
+}
+
+func (vc *baseVCard) GetProperties() []IProperty {
+	return vc.properties
+}
+
+/**
+ * add a property
+ * the representations of a property with the same ALTID are one property for the cardinality (see altid.go)
+ */
+ func (b *baseVCard) AddProperty(p IProperty) {
+
+	if p.GetCardinality() == "1" || p.GetCardinality() == "*1" {
+		// only one property should exists
+		var others []IProperty
+		for _, existing := range b.GetProperty(p.GetName()) {
+			if !isAlternative(p, existing) {
+				others = append(others, existing)
+			}
+		}
+		switch (b.GetAddPropertyScenario()) {
+			case "ignore":
+				if len(others) > 0 {
+					// ignore item
+					return
+				}
+			case "overwrite":
+				// remove the existing properties of the same type (name), except the other representations
+				for _, existing := range others {
+					b.removeProperty(existing)
+				}
+		}
+	}
+
+   b.properties = append(b.properties, p)
+}
+
+/**
+ * return a list of properties; with a group prefix (item1.EMAIL) only the properties of the group
+ */
+ func (b *baseVCard) GetProperty(name string) []IProperty {
+    var result []IProperty
+    for _ , p := range b.properties {
+		if matchProperty(p, name) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+/**
+ * delete all the properties with the given name (and group, if the name has a group prefix)
+ */
+ func (b *baseVCard) DeleteProperty(name string) {
+	kept := b.properties[:0]
+	for _, p := range b.properties {
+		if !matchProperty(p, name) {
+			kept = append(kept, p)
+		}
+	}
+	b.properties = kept
+}
+
+func matchProperty(p IProperty, name string) bool {
+	group, name := splitGroup(name)
+	if group != "" && !strings.EqualFold(p.GetGroup(), group) {
+		return false
+	}
+	return p.GetName() == strings.ToUpper(name)
+}
+
+/**
+ * properties of a group, in the order of the card
+ */
+func (b *baseVCard) GetPropertiesInGroup(group string) []IProperty {
+	var result []IProperty
+	for _, p := range b.properties {
+		if p.GetGroup() != "" && strings.EqualFold(p.GetGroup(), group) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+/**
+ * groups used by the properties, in the order of the card
+ */
+func (b *baseVCard) GetGroups() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, p := range b.properties {
+		g := strings.ToLower(p.GetGroup())
+		if g != "" && !seen[g] {
+			seen[g] = true
+			result = append(result, p.GetGroup())
+		}
+	}
+	return result
+}
+
+/**
+ * delete one property, keeping the others in place
+ */
+func (b *baseVCard) removeProperty(p IProperty) {
+	for i, item := range b.properties {
+		if item == p {
+			b.properties = append(b.properties[:i], b.properties[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * create an empty vcard for a version (2.1, 3.0 or 4.0)
+ */
+func NewVCard(version string) (IVCard, error) {
+	switch (version) {
+		case "2.1":
+			return NewVCardV21(), nil
+		case "3.0":
+			return NewVCardV3(), nil
+		case "4.0":
+			return NewVCardV4(), nil
+	}
+	return nil, fmt.Errorf("vcard: unsupported version %q", version)
+}
+
+/**
+ * value types accepted by a property in a version (see PropertySchema.ValueTypes); nil if the property accepts any type
+ */
+func acceptedValueTypesOf(name string, version string) []string {
+	s, _ := lookupPropertySchema(version, name)
+	return s.ValueTypes
+}
+
+func defaultValueType(name string, version string) string {
+	if types := acceptedValueTypesOf(name, version); len(types) > 0 {
+		return types[0]
+	}
+	return "text"
+}
+
+/**
+ * value type of a property without VALUE parameter: inline binary values are "binary", other media values,
+ * global phone numbers and locations are uris
+ */
+func valueTypeOf(p IProperty, version string) string {
+	switch v := p.GetFirstValue().(type) {
+		case *MediaValue:
+			if !v.IsUrl && !v.IsDataUri && v.IsB64Encoded {
+				return "binary"
+			}
+			return "uri"
+		case *TelValue:
+			if v.IsGlobal() {
+				return "uri"
+			}
+		case *GeoValue:
+			// jCard and xCard write the geo uri in all versions
+			return "uri"
+	}
+	return defaultValueType(p.GetName(), version)
+}
+
+/**
+ * create the value of a non structured property from its unescaped string
+ */
+func newTypedValue(name string, valueType string, s string) IData {
+	switch name {
+		case "GEO":
+			return parseGeo(s)
+		case "PHOTO", "LOGO", "SOUND", "KEY":
+			if valueType == "binary" {
+				p := NewPhoto("")
+				p.IsB64Encoded = true
+				p.SetValue(s)
+				return p
+			}
+			return NewPhoto(s)
+		case "EMAIL":
+			// the bare addresses; a display name or an invalid address is kept as text
+			if e, err := ParseEmail(s); err == nil && e.GetValue() == s {
+				return e
+			}
+		case "TEL":
+			// tel uris; the text values are kept as they are
+			if valueType == "uri" && strings.HasPrefix(strings.ToLower(s), "tel:") {
+				if t, err := ParseTel(s); err == nil {
+					return t
+				}
+			}
+	}
+	if d := newDateTimeData(valueType, s); d != nil {
+		return d
+	}
+	return NewText(s)
+}
@@ -0,0 +1,73 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPropertyGroups(t *testing.T) {
+	s := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:John\r\n" +
+		"N:;John;;;\r\n" +
+		"item1.EMAIL;TYPE=INTERNET:john@example.com\r\n" +
+		"item1.X-ABLabel:Custom\r\n" +
+		"Item2.URL:http://example.com/\r\n" +
+		"item2.X-ABLabel:_$!<HomePage>!$_\r\n" +
+		"EMAIL:other@example.com\r\n" +
+		"END:VCARD\r\n"
+
+	card, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups := card.GetGroups(); !reflect.DeepEqual(groups, []string{"item1", "Item2"}) {
+		t.Errorf("groups %v", groups)
+	}
+	item1 := card.GetPropertiesInGroup("ITEM1")
+	if len(item1) != 2 || item1[0].GetName() != "EMAIL" || item1[1].GetName() != "X-ABLABEL" {
+		t.Errorf("item1 %v", item1)
+	}
+	if len(card.GetProperty("EMAIL")) != 2 || len(card.GetProperty("item1.EMAIL")) != 1 {
+		t.Errorf("EMAIL lookup")
+	}
+	if labels := card.GetProperty("item2.X-ABLabel"); len(labels) != 1 || labels[0].GetFirstValue().GetValue() != "_$!<HomePage>!$_" {
+		t.Errorf("item2 label %v", labels)
+	}
+
+	out := card.Build()
+	for _, want := range []string{"\r\nitem1.EMAIL;TYPE=INTERNET:john@example.com\r\n", "\r\nitem1.X-ABLABEL:Custom\r\n", "\r\nItem2.URL:http://example.com/\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not found in\n%s", want, out)
+		}
+	}
+
+	v4, _, err := Convert(card, "4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v4.GetPropertiesInGroup("item1")) != 2 {
+		t.Errorf("the groups are not converted:\n%s", v4.Build())
+	}
+
+	data, err := MarshalJCard(v4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"group":"item1"`) {
+		t.Errorf("jCard group: %s", data)
+	}
+	back, err := UnmarshalJCard(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.GetPropertiesInGroup("item1")) != 2 {
+		t.Errorf("jCard groups are not read back: %s", data)
+	}
+
+	card.DeleteProperty("item1.EMAIL")
+	if len(card.GetProperty("EMAIL")) != 1 || len(card.GetPropertiesInGroup("item1")) != 1 {
+		t.Errorf("DeleteProperty with a group")
+	}
+}
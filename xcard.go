@@ -0,0 +1,436 @@
+/**
+ * xCard: the XML format for vcard (RFC 6351)
+ *
+ *   <vcards xmlns="urn:ietf:params:xml:ns:vcard-4.0">
+ *     <vcard>
+ *       <fn><text>John Doe</text></fn>
+ *       <n><surname>Doe</surname><given>John</given><additional/><prefix/><suffix/></n>
+ *       <tel>
+ *         <parameters><type><text>work</text><text>voice</text></type></parameters>
+ *         <uri>tel:+1-555-555-5555</uri>
+ *       </tel>
+ *       <group name="item1">
+ *         <email><text>john@example.com</text></email>
+ *       </group>
+ *     </vcard>
+ *   </vcards>
+ */
+package vcard
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const XCardNamespace = "urn:ietf:params:xml:ns:vcard-4.0"
+
+/**
+ * generic xml element, used both to write and to read xcards
+ */
+type xcardNode struct {
+	XMLName xml.Name
+	Attrs []xml.Attr `xml:",any,attr"`
+	Text string `xml:",chardata"`
+	Children []*xcardNode `xml:",any"`
+}
+
+func newXCardNode(name string, text string) *xcardNode {
+	return &xcardNode{XMLName: xml.Name{Local: name}, Text: text}
+}
+
+func (n *xcardNode) add(child *xcardNode) {
+	n.Children = append(n.Children, child)
+}
+
+/**
+ * children with a local name
+ */
+func (n *xcardNode) children(name string) []*xcardNode {
+	var result []*xcardNode
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+/**
+ * text of the children with a local name
+ */
+func (n *xcardNode) childrenText(name string) []string {
+	var result []string
+	for _, c := range n.children(name) {
+		result = append(result, c.Text)
+	}
+	return result
+}
+
+/**
+ * encode cards as an xCard document
+ * vcard 4.0 cards have no version element; for other versions a <version> element is written,
+ * use Convert to get vcard 4.0 cards first
+ */
+func MarshalXCard(cards ...IVCard) ([]byte, error) {
+	// the namespace is declared as an attribute, so the children (without namespace) inherit it
+	root := newXCardNode("vcards", "")
+	root.Attrs = []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: XCardNamespace}}
+	for _, card := range cards {
+		root.add(xcardCard(card))
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func xcardCard(card IVCard) *xcardNode {
+	node := newXCardNode("vcard", "")
+	if card.GetVersion() != "4.0" {
+		version := newXCardNode("version", "")
+		version.add(newXCardNode("text", card.GetVersion()))
+		node.add(version)
+	}
+
+	// the properties of a group are written in a <group> element, at the position of the first one
+	groups := map[string]*xcardNode{}
+	for _, p := range card.GetProperties() {
+		parent := node
+		if p.GetGroup() != "" {
+			key := strings.ToLower(p.GetGroup())
+			if groups[key] == nil {
+				groups[key] = newXCardNode("group", "")
+				groups[key].Attrs = []xml.Attr{{Name: xml.Name{Local: "name"}, Value: p.GetGroup()}}
+				node.add(groups[key])
+			}
+			parent = groups[key]
+		}
+
+		switch p.GetName() {
+			case "BEGIN", "END", "VERSION":
+				continue
+			case "XML":
+				// the xml values are written as they are
+				if xmlNode := xcardXmlProperty(p); xmlNode != nil {
+					parent.add(xmlNode)
+					continue
+				}
+		}
+		parent.add(xcardProperty(card, p))
+	}
+	return node
+}
+
+func xcardProperty(card IVCard, p IProperty) *xcardNode {
+	node := newXCardNode(strings.ToLower(p.GetName()), "")
+
+	valueType := ""
+	params := newXCardNode("parameters", "")
+	for _, param := range p.GetParameters() {
+		name := param.GetName()
+		if name == "VALUE" {
+			if len(param.GetValue()) > 0 {
+				valueType = strings.ToLower(param.GetValue()[0])
+			}
+			continue
+		}
+		paramNode := newXCardNode(strings.ToLower(name), "")
+		for _, v := range param.GetValue() {
+			paramNode.add(newXCardNode(xcardParameterType(name), v))
+		}
+		params.add(paramNode)
+	}
+	if len(params.Children) > 0 {
+		node.add(params)
+	}
+
+	if valueType == "" {
+		valueType = valueTypeOf(p, card.GetVersion())
+		if strings.HasPrefix(p.GetName(), "X-") && valueType == "text" {
+			// extension properties without a known type
+			valueType = "unknown"
+		}
+	}
+
+	for _, v := range p.GetValue() {
+		switch d := v.(type) {
+			case *NameValue:
+				xcardComponents(node, "surname", d.FamilyName)
+				xcardComponents(node, "given", d.GivenName)
+				xcardComponents(node, "additional", d.MiddleName)
+				xcardComponents(node, "prefix", d.HonorificPrefixes)
+				xcardComponents(node, "suffix", d.HonorificSuffixes)
+			case *AddressValue:
+				for i, c := range d.Components() {
+					xcardComponents(node, addressComponents[i], c)
+				}
+			case *OrganizationValue:
+				node.add(newXCardNode("text", d.Company))
+				for _, dep := range d.Departments {
+					node.add(newXCardNode("text", dep))
+				}
+			case *GenderValue:
+				node.add(newXCardNode("sex", d.Sex))
+				if d.Identity != "" {
+					node.add(newXCardNode("identity", d.Identity))
+				}
+			case *StructuredValue:
+				names := propertyComponents(p)
+				for i, c := range d.Components {
+					name := "text"
+					if i < len(names) {
+						name = names[i]
+					}
+					xcardComponents(node, name, c)
+				}
+			case *GeoValue, *MediaValue:
+				// uris, not escaped
+				node.add(newXCardNode(valueType, v.GetString()))
+			default:
+				node.add(newXCardNode(valueType, v.GetValue()))
+		}
+	}
+	return node
+}
+
+/**
+ * a structured component with more values is written as repeated elements; an empty component as an empty element
+ */
+func xcardComponents(node *xcardNode, name string, values []string) {
+	if len(values) == 0 {
+		node.add(newXCardNode(name, ""))
+	}
+	for _, v := range values {
+		node.add(newXCardNode(name, v))
+	}
+}
+
+/**
+ * value type of the parameter values
+ */
+func xcardParameterType(name string) string {
+	switch (name) {
+		case "PREF":
+			return "integer"
+		case "GEO":
+			return "uri"
+		case "LANGUAGE":
+			return "language-tag"
+	}
+	return "text"
+}
+
+/**
+ * the value of an XML property is the xml of an extension element
+ */
+func xcardXmlProperty(p IProperty) *xcardNode {
+	v := p.GetFirstValue()
+	if v == nil {
+		return nil
+	}
+	node := &xcardNode{}
+	if err := xml.Unmarshal([]byte(v.GetValue()), node); err != nil {
+		return nil
+	}
+	node.stripNamespaceAttrs()
+	return node
+}
+
+/**
+ * remove the namespace declarations read by xml.Unmarshal; the encoder writes them again from the element names
+ */
+func (n *xcardNode) stripNamespaceAttrs() {
+	var attrs []xml.Attr
+	for _, a := range n.Attrs {
+		if a.Name.Space != "xmlns" && a.Name.Local != "xmlns" {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attrs = attrs
+	for _, c := range n.Children {
+		c.stripNamespaceAttrs()
+	}
+}
+
+/**
+ * decode an xCard document (<vcards> with one or more <vcard> elements, or a single <vcard>)
+ */
+func UnmarshalXCard(data []byte) ([]IVCard, error) {
+	root := &xcardNode{}
+	if err := xml.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Space != XCardNamespace {
+		return nil, errors.New("vcard: not an xcard document")
+	}
+
+	var cardNodes []*xcardNode
+	switch (root.XMLName.Local) {
+		case "vcards":
+			cardNodes = root.children("vcard")
+		case "vcard":
+			cardNodes = []*xcardNode{root}
+		default:
+			return nil, fmt.Errorf("vcard: unexpected xcard root element %s", root.XMLName.Local)
+	}
+
+	var cards []IVCard
+	for _, cn := range cardNodes {
+		card, err := xcardCardFromNode(cn)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func xcardCardFromNode(cn *xcardNode) (IVCard, error) {
+	version := "4.0"
+	for _, v := range cn.children("version") {
+		if texts := v.childrenText("text"); len(texts) > 0 {
+			version = strings.TrimSpace(texts[0])
+		}
+	}
+	vc, err := NewVCard(version)
+	if err != nil {
+		return nil, err
+	}
+
+	xcardAddProperties(vc, cn.Children, "")
+	return vc, nil
+}
+
+/**
+ * add the properties of xml elements to a card; the properties of a <group name="..."> element get the group
+ */
+func xcardAddProperties(vc IVCard, nodes []*xcardNode, group string) {
+	version := vc.GetVersion()
+	for _, pn := range nodes {
+		if pn.XMLName.Space == XCardNamespace && pn.XMLName.Local == "group" {
+			for _, a := range pn.Attrs {
+				if a.Name.Local == "name" {
+					xcardAddProperties(vc, pn.Children, a.Value)
+				}
+			}
+			continue
+		}
+
+		if pn.XMLName.Space != XCardNamespace {
+			// extension element from another namespace: kept in a XML property
+			p := vc.CreateProperty("XML")
+			p.SetGroup(group)
+			p.AddValue(NewText(xcardNodeString(pn)))
+			vc.AddProperty(p)
+			continue
+		}
+
+		name := strings.ToUpper(pn.XMLName.Local)
+		switch name {
+			case "BEGIN", "END", "VERSION":
+				continue
+		}
+
+		p := vc.CreateProperty(name)
+		p.SetGroup(group)
+
+		valueType := ""
+		var valueNodes []*xcardNode
+		for _, c := range pn.Children {
+			if c.XMLName.Local != "parameters" {
+				valueNodes = append(valueNodes, c)
+				continue
+			}
+			for _, param := range c.Children {
+				var values []string
+				for _, pv := range param.Children {
+					values = append(values, pv.Text)
+				}
+				if len(param.Children) == 0 {
+					values = append(values, strings.TrimSpace(param.Text))
+				}
+				vc.AddPropertyParameter(p, param.XMLName.Local, values)
+			}
+		}
+
+		switch name {
+			case "N":
+				n := NewName()
+				for _, s := range pn.childrenText("surname") {
+					n.AddFamilyName(s)
+				}
+				for _, s := range pn.childrenText("given") {
+					n.AddGivenName(s)
+				}
+				for _, s := range pn.childrenText("additional") {
+					n.AddMiddleName(s)
+				}
+				for _, s := range pn.childrenText("prefix") {
+					n.AddHonorificPrefix(s)
+				}
+				for _, s := range pn.childrenText("suffix") {
+					n.AddHonorificSuffix(s)
+				}
+				p.AddValue(n)
+			case "ADR":
+				a := NewAddress()
+				var c [][]string
+				for _, name := range addressComponents {
+					c = append(c, pn.childrenText(name))
+				}
+				a.SetComponents(c)
+				p.AddValue(a)
+			case "ORG":
+				texts := pn.childrenText("text")
+				if len(texts) > 0 {
+					p.AddValue(NewOrganization(texts[0], texts[1:]))
+				}
+			case "GENDER":
+				g := NewGender("", "")
+				if sex := pn.childrenText("sex"); len(sex) > 0 {
+					g.Sex = sex[0]
+				}
+				if identity := pn.childrenText("identity"); len(identity) > 0 {
+					g.Identity = identity[0]
+				}
+				p.AddValue(g)
+			default:
+				if components := propertyComponents(p); len(components) > 0 {
+					var parts [][]string
+					for _, c := range components {
+						parts = append(parts, pn.childrenText(c))
+					}
+					p.AddValue(NewStructured(parts))
+					break
+				}
+				if len(valueNodes) > 0 {
+					valueType = valueNodes[0].XMLName.Local
+				}
+				// the VALUE parameter is set before the values, they are checked against it
+				if valueType != "" && valueType != "unknown" && valueType != defaultValueType(name, version) {
+					vc.AddPropertyParameter(p, "VALUE", []string{valueType})
+				}
+				for _, vn := range valueNodes {
+					p.AddValue(newTypedValue(name, vn.XMLName.Local, vn.Text))
+				}
+		}
+
+		vc.AddProperty(p)
+	}
+}
+
+/**
+ * xml of an extension element
+ */
+func xcardNodeString(n *xcardNode) string {
+	n.stripNamespaceAttrs()
+	data, err := xml.Marshal(n)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
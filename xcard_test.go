@@ -0,0 +1,82 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXCardRoundTrip(t *testing.T) {
+	card, err := Parse("BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"FN:John Doe\r\n" +
+		"N:Doe;John;;Dr.;\r\n" +
+		"ADR;TYPE=work:;Suite 5;1 Main St;Town;;12345;USA\r\n" +
+		"TEL;VALUE=uri;TYPE=work,voice:tel:+15551234567\r\n" +
+		"X-FOO;X-BAR=baz:custom value\r\n" +
+		"END:VCARD\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalXCard(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := strings.Join(strings.Fields(string(data)), "")
+	for _, want := range []string{
+		`<vcardsxmlns="urn:ietf:params:xml:ns:vcard-4.0">`,
+		`<n><surname>Doe</surname><given>John</given><additional></additional><prefix>Dr.</prefix><suffix></suffix></n>`,
+		`<adr><parameters><type><text>work</text></type></parameters><pobox></pobox><ext>Suite5</ext><street>1MainSt</street><locality>Town</locality><region></region><code>12345</code><country>USA</country></adr>`,
+		`<type><text>work</text><text>voice</text></type>`,
+		`<uri>tel:+15551234567</uri>`,
+		`<x-foo><parameters><x-bar><text>baz</text></x-bar></parameters><unknown>customvalue</unknown></x-foo>`,
+	} {
+		if !strings.Contains(x, want) {
+			t.Errorf("%s not found in\n%s", want, data)
+		}
+	}
+
+	cards, err := UnmarshalXCard(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("%d cards", len(cards))
+	}
+	// the VALUE parameter is written in the value element: the parameters are compared in canonical order
+	build := func(card IVCard) string {
+		b := NewBuilder(card)
+		b.SetParameterOrder("alphabetical")
+		return b.Build()
+	}
+	if build(cards[0]) != build(card) {
+		t.Errorf("xCard round trip:\n%s\n%s", build(card), build(cards[0]))
+	}
+}
+
+func TestXCardExtensionElements(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<vcards xmlns="urn:ietf:params:xml:ns:vcard-4.0">
+  <vcard>
+    <fn><text>John</text></fn>
+    <foo xmlns="http://example.com/ns" k="v"><bar>1</bar></foo>
+  </vcard>
+</vcards>`
+
+	cards, err := UnmarshalXCard([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := cards[0].GetProperty("XML")
+	if len(ps) != 1 || !strings.Contains(ps[0].GetFirstValue().GetValue(), "http://example.com/ns") {
+		t.Fatalf("the extension element is not kept: %v", ps)
+	}
+
+	out, err := MarshalXCard(cards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `<foo xmlns="http://example.com/ns" k="v">`) {
+		t.Errorf("the extension element is not written back:\n%s", out)
+	}
+}